@@ -0,0 +1,303 @@
+package panda
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// defaultPerPage is the page size used when walking through all pages of a listing
+const defaultPerPage = 100
+
+// BatchOptions configure bulk operations. Bulk operations run with the context of
+// the Manager they are called on, see Manager.WithContext. Once it is done no
+// further items are started and those left fail with the context's error.
+type BatchOptions struct {
+	// Workers is the number of concurrent calls, defaults to 4
+	Workers int
+	// DryRun reports what would be done without calling Panda. An existing
+	// checkpoint is read but never written.
+	DryRun bool
+	// Checkpoint is the path of a file recording completed items. Items found in
+	// it are skipped, so an interrupted batch can be resumed by running it again
+	// with the same checkpoint.
+	Checkpoint string
+}
+
+// BatchResult is the outcome of a single item of a bulk operation
+type BatchResult struct {
+	// Key identifies the item in the checkpoint file
+	Key         string
+	VideoID     string
+	ProfileName string
+	EncodingID  string
+	// Skipped is true if the item was already completed according to the checkpoint
+	Skipped bool
+	DryRun  bool
+	Err     error
+	// CheckpointErr is set if the item succeeded but could not be recorded in the
+	// checkpoint, so it is done again when the batch is resumed
+	CheckpointErr error
+}
+
+// BatchReport holds the results of a bulk operation in the order of its items
+type BatchReport struct {
+	Results []BatchResult
+}
+
+// Failed returns the results of all items which failed
+func (r *BatchReport) Failed() (rs []BatchResult) {
+	for _, res := range r.Results {
+		if res.Err != nil {
+			rs = append(rs, res)
+		}
+	}
+	return
+}
+
+// Succeeded returns the number of items which were completed by this run
+func (r *BatchReport) Succeeded() (n int) {
+	for _, res := range r.Results {
+		if res.Err == nil && !res.Skipped && !res.DryRun {
+			n++
+		}
+	}
+	return
+}
+
+// EncodeAll creates an encoding for every combination of the given video IDs and
+// profile names
+func (m *Manager) EncodeAll(videoIDs, profileNames []string, opts *BatchOptions) (*BatchReport, error) {
+	items := make([]BatchResult, 0, len(videoIDs)*len(profileNames))
+	for _, v := range videoIDs {
+		for _, p := range profileNames {
+			items = append(items, BatchResult{Key: "encode/" + v + "/" + p, VideoID: v, ProfileName: p})
+		}
+	}
+	return runBatch(m.Client.context(), items, opts, func(res *BatchResult) error {
+		e, err := m.NewEncoding(&NewEncodingRequest{VideoID: res.VideoID, ProfileName: res.ProfileName})
+		if err == nil {
+			res.EncodingID = e.ID
+		}
+		return err
+	})
+}
+
+// CancelAll cancels all encodings matching the given filter. Unless er.Page is set
+// all pages of the listing are processed.
+func (m *Manager) CancelAll(er *EncodingRequest, opts *BatchOptions) (*BatchReport, error) {
+	return m.encodingsBatch("cancel", er, opts, m.Cancel)
+}
+
+// RetryAll retries all encodings matching the given filter. Unless er.Page is set
+// all pages of the listing are processed.
+func (m *Manager) RetryAll(er *EncodingRequest, opts *BatchOptions) (*BatchReport, error) {
+	return m.encodingsBatch("retry", er, opts, m.Retry)
+}
+
+func (m *Manager) encodingsBatch(op string, er *EncodingRequest, opts *BatchOptions,
+	fn func(id string) error) (*BatchReport, error) {
+	es, err := m.AllEncodings(er)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]BatchResult, len(es))
+	for i, e := range es {
+		items[i] = BatchResult{
+			Key:         op + "/" + e.ID,
+			VideoID:     e.VideoID,
+			ProfileName: e.ProfileName,
+			EncodingID:  e.ID,
+		}
+	}
+	return runBatch(m.Client.context(), items, opts, func(res *BatchResult) error {
+		return fn(res.EncodingID)
+	})
+}
+
+// AllEncodings gets encodings matching the given filter from all pages of the
+// listing, until a page has no new encodings. If er.Page is set only that page is
+// fetched.
+func (m *Manager) AllEncodings(er *EncodingRequest) ([]Encoding, error) {
+	req := EncodingRequest{}
+	if er != nil {
+		req = *er
+	}
+	if req.Page != 0 {
+		return m.Encodings(&req)
+	}
+	if req.PerPage == 0 {
+		req.PerPage = defaultPerPage
+	}
+	return allPages(func(page int) ([]Encoding, error) {
+		req.Page = page
+		return m.Encodings(&req)
+	}, func(e *Encoding) string { return e.ID })
+}
+
+// allPages calls fetch for the pages of a listing starting with the first one and
+// returns their items, deduplicated by id. The server may return fewer items than
+// asked for, so only a page without new items ends the listing.
+func allPages[T any](fetch func(page int) ([]T, error), id func(*T) string) ([]T, error) {
+	var all []T
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		items, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+		n := len(all)
+		for i := range items {
+			if k := id(&items[i]); !seen[k] {
+				seen[k] = true
+				all = append(all, items[i])
+			}
+		}
+		if len(all) == n {
+			return all, nil
+		}
+	}
+}
+
+type checkpointEntry struct {
+	Key        string `json:"key"`
+	EncodingID string `json:"encoding_id,omitempty"`
+}
+
+type checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]checkpointEntry
+}
+
+// openCheckpoint reads the checkpoint at path and opens it for appending, unless
+// readOnly is set. Malformed lines are skipped; a partial last line left by a
+// crash is terminated so that it does not swallow the next entry.
+func openCheckpoint(path string, readOnly bool) (*checkpoint, error) {
+	cp := &checkpoint{done: map[string]checkpointEntry{}}
+	if path == "" {
+		return cp, nil
+	}
+	var f *os.File
+	var err error
+	if readOnly {
+		if f, err = os.Open(path); os.IsNotExist(err) {
+			return cp, nil
+		}
+	} else {
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	var last []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			last = line
+			var e checkpointEntry
+			if json.Unmarshal(line, &e) == nil {
+				cp.done[e.Key] = e
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if readOnly {
+		return cp, f.Close()
+	}
+	if len(last) > 0 && last[len(last)-1] != '\n' {
+		if _, err = f.Write([]byte{'\n'}); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	cp.f = f
+	return cp, nil
+}
+
+func (cp *checkpoint) record(res *BatchResult) error {
+	if cp.f == nil {
+		return nil
+	}
+	b, err := json.Marshal(checkpointEntry{Key: res.Key, EncodingID: res.EncodingID})
+	if err != nil {
+		return err
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	_, err = cp.f.Write(append(b, '\n'))
+	return err
+}
+
+func (cp *checkpoint) close() error {
+	if cp.f == nil {
+		return nil
+	}
+	return cp.f.Close()
+}
+
+// runBatch calls fn for all items not completed according to the checkpoint. Once
+// ctx is done the items not yet started fail with ctx.Err(), which is returned
+// along with the report.
+func runBatch(ctx context.Context, items []BatchResult, opts *BatchOptions,
+	fn func(*BatchResult) error) (*BatchReport, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	cp, err := openCheckpoint(opts.Checkpoint, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	report := &BatchReport{Results: items}
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				res := &report.Results[i]
+				if e, ok := cp.done[res.Key]; ok {
+					res.Skipped = true
+					if res.EncodingID == "" {
+						res.EncodingID = e.EncodingID
+					}
+					continue
+				}
+				if opts.DryRun {
+					res.DryRun = true
+					continue
+				}
+				if res.Err = ctx.Err(); res.Err != nil {
+					continue
+				}
+				if res.Err = fn(res); res.Err == nil {
+					res.CheckpointErr = cp.record(res)
+				}
+			}
+		}()
+	}
+	for i := range items {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+	if err := cp.close(); err != nil {
+		return report, err
+	}
+	return report, ctx.Err()
+}
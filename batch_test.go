@@ -0,0 +1,183 @@
+package panda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEncodeAll(t *testing.T) {
+	var mu sync.Mutex
+	created := map[string]int{}
+	fail := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		key := q.Get("video_id") + "-" + q.Get("profile_name")
+		if key == "v2-webm" && fail {
+			w.WriteHeader(http.StatusBadRequest)
+			mustWrite(w, []byte(`{"error":"BadRequest"}`))
+			return
+		}
+		created[key]++
+		mustWrite(w, []byte(fmt.Sprintf(`{"id":%q}`, key)))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	opts := &BatchOptions{Workers: 2, Checkpoint: filepath.Join(t.TempDir(), "checkpoint")}
+	videos, profiles := []string{"v1", "v2"}, []string{"h264", "webm"}
+
+	dry, err := m.EncodeAll(videos, profiles, &BatchOptions{DryRun: true, Checkpoint: opts.Checkpoint})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(dry.Results) != 4 || len(created) != 0 || !dry.Results[3].DryRun {
+		t.Fatalf("want dry run to create nothing; got %v", created)
+	}
+	if _, err := os.Stat(opts.Checkpoint); !os.IsNotExist(err) {
+		t.Errorf("want dry run not to create the checkpoint; got err=%v", err)
+	}
+
+	report, err := m.EncodeAll(videos, profiles, opts)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Key != "encode/v2/webm" {
+		t.Errorf("want encode/v2/webm to fail; got %v", failed)
+	}
+	if n := report.Succeeded(); n != 3 {
+		t.Errorf("want 3 succeeded; got %d", n)
+	}
+	if id := report.Results[0].EncodingID; id != "v1-h264" {
+		t.Errorf("want encoding id=v1-h264; got %s", id)
+	}
+
+	// a crash while writing leaves a partial line behind
+	f, err := os.OpenFile(opts.Checkpoint, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteString(`{"key":"encode/v2`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	report, err = m.EncodeAll(videos, profiles, opts)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if n := report.Succeeded(); n != 1 || len(report.Failed()) != 0 {
+		t.Errorf("want only the failed item to be resumed; got %d succeeded", n)
+	}
+	if !report.Results[0].Skipped || report.Results[0].EncodingID != "v1-h264" {
+		t.Errorf("want skipped item to keep its encoding id; got %#v", report.Results[0])
+	}
+	dry, err = m.EncodeAll(videos, profiles, &BatchOptions{DryRun: true, Checkpoint: opts.Checkpoint})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	for _, res := range dry.Results {
+		if !res.Skipped {
+			t.Errorf("want all items recorded in the checkpoint; got %#v", res)
+		}
+	}
+	for k, n := range created {
+		if n != 1 {
+			t.Errorf("want %s to be created once; got %d", k, n)
+		}
+	}
+}
+
+func TestRetryAll(t *testing.T) {
+	var mu sync.Mutex
+	var retried []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/retry.json") {
+			retried = append(retried, strings.Split(r.URL.Path, "/")[3])
+			mustWrite(w, []byte(`{}`))
+			return
+		}
+		if r.URL.Query().Get("status") != string(StatusFail) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// the server caps per_page at 2
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var es []Encoding
+		for i := 0; i < 2 && (page-1)*2+i < 3; i++ {
+			es = append(es, Encoding{ID: "e" + strconv.Itoa((page-1)*2+i)})
+		}
+		b, _ := json.Marshal(es)
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	report, err := m.RetryAll(&EncodingRequest{Status: StatusFail}, nil)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	sort.Strings(retried)
+	if len(report.Results) != 3 || strings.Join(retried, ",") != "e0,e1,e2" {
+		t.Errorf("want e0,e1,e2 to be retried; got %v", retried)
+	}
+}
+
+func TestEncodeAllCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var created int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&created, 1)
+		cancel()
+		mustWrite(w, []byte(`{"id":"e1"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t).WithContext(ctx)
+	report, err := m.EncodeAll([]string{"v1", "v2", "v3"}, []string{"h264"}, &BatchOptions{Workers: 1})
+	if err != context.Canceled {
+		t.Errorf("want err=%v; got %v", context.Canceled, err)
+	}
+	if created != 1 {
+		t.Errorf("want 1 request before the cancellation; got %d", created)
+	}
+	// the first item may fail too, depending on when its call notices the cancellation
+	if failed := report.Failed(); len(failed) < 2 {
+		t.Errorf("want the remaining items to fail; got %v", failed)
+	}
+	if res := report.Results[2]; res.Err != context.Canceled {
+		t.Errorf("want err=%v; got %v", context.Canceled, res.Err)
+	}
+}
+
+func TestBatchReport(t *testing.T) {
+	r := &BatchReport{Results: []BatchResult{
+		{Key: "a"},
+		{Key: "b", Err: errors.New("failed")},
+		{Key: "c", CheckpointErr: errors.New("disk full")},
+		{Key: "d", Skipped: true},
+		{Key: "e", DryRun: true},
+	}}
+	if n := r.Succeeded(); n != 2 {
+		t.Errorf("want 2 succeeded; got %d", n)
+	}
+	if failed := r.Failed(); len(failed) != 1 || failed[0].Key != "b" {
+		t.Errorf("want b to fail; got %v", failed)
+	}
+}
@@ -0,0 +1,96 @@
+// Package boltstore provides a panda.EventStore persisted in a BoltDB file
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/pandastream/go-panda"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	keysBucket   = []byte("keys")
+	eventsBucket = []byte("events")
+)
+
+// Store is a panda.EventStore keeping processed events in a BoltDB file. Events are
+// stored under increasing sequence numbers, so they are replayed in the order they
+// were saved.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store in the file at the given path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(keysBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(eventsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Seen(key string) (seen bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		seen = tx.Bucket(keysBucket).Get([]byte(key)) != nil
+		return nil
+	})
+	return
+}
+
+func (s *Store) Save(e *panda.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		keys := tx.Bucket(keysBucket)
+		if keys.Get([]byte(e.Key())) != nil {
+			return nil
+		}
+		events := tx.Bucket(eventsBucket)
+		seq, err := events.NextSequence()
+		if err != nil {
+			return err
+		}
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, seq)
+		if err := keys.Put([]byte(e.Key()), id); err != nil {
+			return err
+		}
+		return events.Put(id, b)
+	})
+}
+
+func (s *Store) Events(since time.Time) (es []*panda.Event, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(_, v []byte) error {
+			e := new(panda.Event)
+			if err := json.Unmarshal(v, e); err != nil {
+				return err
+			}
+			if !e.Received.Before(since) {
+				es = append(es, e)
+			}
+			return nil
+		})
+	})
+	return
+}
@@ -0,0 +1,50 @@
+package boltstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pandastream/go-panda"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	now := time.Now().UTC()
+	events := []*panda.Event{
+		{Type: panda.EventVideoCreated, VideoID: "v1", Received: now.Add(-time.Hour)},
+		{Type: panda.EventEncodingProgress, EncodingID: "e1", Progress: 50, Received: now},
+		{Type: panda.EventEncodingProgress, EncodingID: "e1", Progress: 50, Received: now},
+		{Type: panda.EventEncodingCompleted, EncodingID: "e1", Received: now},
+	}
+	for _, e := range events {
+		if err := s.Save(e); err != nil {
+			t.Fatalf("want err=nil; got %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = Open(path); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	defer s.Close()
+	if seen, err := s.Seen(events[1].Key()); err != nil || !seen {
+		t.Errorf("want event to be seen after reopening; got %v (err=%v)", seen, err)
+	}
+	if seen, _ := s.Seen("encoding-complete/e2/0"); seen {
+		t.Error("want unknown event not to be seen")
+	}
+	es, err := s.Events(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(es) != 2 || es[0].Type != panda.EventEncodingProgress || es[1].Type != panda.EventEncodingCompleted {
+		t.Errorf("want progress and completed events in order; got %v", es)
+	}
+}
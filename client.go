@@ -1,6 +1,7 @@
 package panda
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -8,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
@@ -37,6 +39,28 @@ type Client struct {
 	Host       string
 	Options    *ClientOptions
 	HTTPClient *http.Client
+	// Middleware wraps every call made by the client, the first one being the outermost
+	Middleware []Middleware
+	// Logger receives debug logs of every request and response if set. Signatures,
+	// keys and tokens are always redacted.
+	Logger *slog.Logger
+
+	ctx context.Context
+}
+
+// WithContext returns a shallow copy of the client whose calls carry the given
+// context, e.g. to cancel them or to make them part of the caller's trace
+func (cl *Client) WithContext(ctx context.Context) *Client {
+	c := *cl
+	c.ctx = ctx
+	return &c
+}
+
+func (cl *Client) context() context.Context {
+	if cl.ctx != nil {
+		return cl.ctx
+	}
+	return context.Background()
 }
 
 func (cl *Client) hostPort() string {
@@ -81,6 +105,10 @@ func (cl *Client) fixQuery(s string) string {
 
 func (cl *Client) buildSignature(v url.Values, method, u string) (sign string, err error) {
 	toSign := fmt.Sprintf("%s\n%s\n%s\n%s", method, cl.host(), u, cl.fixQuery(v.Encode()))
+	if cl.Logger != nil {
+		cl.debug("panda: string to sign", "value", fmt.Sprintf("%s\n%s\n%s\n%s",
+			method, cl.host(), u, redactQuery(cl.fixQuery(v.Encode()))))
+	}
 	mac := hmac.New(sha256.New, []byte(cl.Options.SecretKey))
 	if _, err = mac.Write([]byte(toSign)); err == nil {
 		sign = base64.StdEncoding.EncodeToString(mac.Sum(nil))
@@ -101,28 +129,35 @@ func (cl *Client) buildURL(v url.Values, urlPath string) *url.URL {
 	}
 }
 
-func (cl *Client) do(method, path, cntType string,
-	params url.Values, r io.Reader) (b []byte, err error) {
-	if params == nil {
-		params = url.Values{}
+func (cl *Client) do(op, method, path, cntType string,
+	params url.Values, r io.Reader) ([]byte, error) {
+	return cl.Do(&Call{
+		Operation:   op,
+		Method:      method,
+		Path:        path,
+		ContentType: cntType,
+		Params:      params,
+		Body:        r,
+	})
+}
+
+// Do sends the call through the client's middleware chain and then, signed, to
+// the Panda Cloud. Responses with a status other than 200 are returned as *Error.
+func (cl *Client) Do(c *Call) (b []byte, err error) {
+	if c.Params == nil {
+		c.Params = url.Values{}
 	}
-	if err = cl.authParams(method, path, params); err != nil {
-		return
+	if c.Context == nil {
+		c.Context = cl.context()
 	}
-	req, err := http.NewRequest(method, cl.buildURL(params, path).String(), r)
-	if err != nil {
-		return
+	if c.Template == "" {
+		c.Template = PathTemplate(c.Path, pathFormats...)
 	}
-	req.Header.Set("Content-Type", cntType)
-	resp, err := cl.httpclient().Do(req)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
-	b, err = ioutil.ReadAll(resp.Body)
+	resp, err := cl.chain(cl.send)(c)
 	if err != nil {
 		return
 	}
+	b = resp.Body
 	if resp.StatusCode != http.StatusOK {
 		e := &Error{Code: resp.StatusCode}
 		if err = json.Unmarshal(b, e); err != nil {
@@ -133,6 +168,45 @@ func (cl *Client) do(method, path, cntType string,
 	return
 }
 
+// send is the last handler of the middleware chain, it signs the call and
+// issues the HTTP request
+func (cl *Client) send(c *Call) (*Response, error) {
+	params := url.Values{}
+	for k, v := range c.Params {
+		params[k] = append([]string(nil), v...)
+	}
+	if err := cl.authParams(c.Method, c.Path, params); err != nil {
+		return nil, err
+	}
+	ctx := c.Context
+	if ctx == nil {
+		ctx = cl.context()
+	}
+	req, err := http.NewRequestWithContext(ctx, c.Method, cl.buildURL(params, c.Path).String(), c.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", c.ContentType)
+	if cl.Logger != nil {
+		cl.debug("panda: request", "operation", c.Operation, "method", c.Method,
+			"url", cl.buildURL(redact(params), c.Path).String(), "params", redact(c.Params).Encode())
+	}
+	resp, err := cl.httpclient().Do(req)
+	if err != nil {
+		cl.debug("panda: request failed", "operation", c.Operation, "error", redactError(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if cl.Logger != nil {
+		cl.debug("panda: response", "operation", c.Operation, "status", resp.StatusCode, "body", redactBody(b))
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: b}, nil
+}
+
 func (cl *Client) authParams(method, path string, params url.Values) error {
 	if cl.Options.Token != "" {
 		params.Add("token", cl.Options.Token)
@@ -158,23 +232,23 @@ func (cl *Client) SignParams(method, path string, params url.Values) error {
 
 // Get issues a signed GET request to the Panda Cloud
 func (cl *Client) Get(url string, params url.Values) ([]byte, error) {
-	return cl.do("GET", url, "", params, nil)
+	return cl.do("", "GET", url, "", params, nil)
 }
 
 // Post issues a signed POST request to the Panda Cloud and creates content based on
 // the given params
 func (cl *Client) Post(url, cntType string, params url.Values, r io.Reader) ([]byte, error) {
-	return cl.do("POST", url, cntType, params, r)
+	return cl.do("", "POST", url, cntType, params, r)
 }
 
 // Put issues a signed PUT request to the Panda Cloud and updates object according to
 // given params
 func (cl *Client) Put(url, cntType string, params url.Values, r io.Reader) ([]byte, error) {
-	return cl.do("PUT", url, cntType, params, r)
+	return cl.do("", "PUT", url, cntType, params, r)
 }
 
 // Delete issues a signed DELETE request to the Panda Cloud and deletes content under
 // the given url
 func (cl *Client) Delete(url string) ([]byte, error) {
-	return cl.do("DELETE", url, "", nil, nil)
+	return cl.do("", "DELETE", url, "", nil, nil)
 }
@@ -79,3 +79,22 @@ func TestBuildUrl(t *testing.T) {
 		}
 	}
 }
+
+func TestPathTemplate(t *testing.T) {
+	cases := []struct {
+		path string
+		exp  string
+	}{
+		{"/videos.json", "/videos.json"},
+		{"/videos/upload.json", "/videos/upload.json"},
+		{"/videos/123.json", "/videos/{id}.json"},
+		{"/videos/123/encodings.json", "/videos/{id}/encodings.json"},
+		{"/encodings/abc/cancel.json", "/encodings/{id}/cancel.json"},
+		{"/unknown/abc.json", "/unknown/abc.json"},
+	}
+	for i, cas := range cases {
+		if res := PathTemplate(cas.path, pathFormats...); res != cas.exp {
+			t.Errorf("want template=%s; got %s (i=%d)", cas.exp, res, i)
+		}
+	}
+}
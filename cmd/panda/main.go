@@ -0,0 +1,63 @@
+// Command panda runs maintenance tasks against a Panda cloud.
+//
+// Credentials are taken from the PANDA_HOST, PANDA_CLOUD_ID, PANDA_ACCESS_KEY and
+// PANDA_SECRET_KEY environment variables or the matching flags:
+//
+//	panda [-host host] [-cloud id] [-access key] [-secret key] command [flags]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pandastream/go-panda"
+)
+
+type command struct {
+	usage string
+	run   func(m *panda.Manager, args []string) error
+}
+
+var commands = map[string]command{
+	"retry-failed": {"retry failed encodings grouped by error and profile", retryFailed},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: panda [flags] command [flags]\n\nflags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	opts := &panda.ClientOptions{}
+	host := flag.String("host", os.Getenv("PANDA_HOST"), "Panda API host")
+	flag.StringVar(&opts.CloudID, "cloud", os.Getenv("PANDA_CLOUD_ID"), "cloud ID")
+	flag.StringVar(&opts.AccessKey, "access", os.Getenv("PANDA_ACCESS_KEY"), "access key")
+	flag.StringVar(&opts.SecretKey, "secret", os.Getenv("PANDA_SECRET_KEY"), "secret key")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "panda: unknown command %q\n", flag.Arg(0))
+		usage()
+	}
+	m := &panda.Manager{Client: &panda.Client{Host: *host, Options: opts}}
+	if err := cmd.run(m, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "panda: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pandastream/go-panda"
+)
+
+func retryFailed(m *panda.Manager, args []string) error {
+	fs := flag.NewFlagSet("retry-failed", flag.ExitOnError)
+	class := fs.String("class", "", "only retry encodings with this error class")
+	profile := fs.String("profile", "", "only retry encodings of this profile")
+	match := fs.String("match", "", "only retry encodings whose error message contains this text")
+	list := fs.Bool("list", false, "only list the groups of failed encodings")
+	opts := &panda.BatchOptions{}
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would be retried")
+	fs.IntVar(&opts.Workers, "workers", 4, "number of concurrent retries")
+	fs.StringVar(&opts.Checkpoint, "checkpoint", "", "file recording retried encodings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	selected := func(g *panda.FailureGroup) bool {
+		return (*class == "" || g.ErrorClass == *class) &&
+			(*profile == "" || g.ProfileName == *profile) &&
+			strings.Contains(g.ErrorMessage, *match)
+	}
+	if *list {
+		gs, err := m.FailedEncodings()
+		if err != nil {
+			return err
+		}
+		printGroups(gs, selected)
+		return nil
+	}
+	gs, report, err := m.RetryFailed(selected, opts)
+	if err != nil {
+		return err
+	}
+	printGroups(gs, nil)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nENCODING\tVIDEO\tPROFILE\tRESULT")
+	for _, res := range report.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.EncodingID, res.VideoID, res.ProfileName, outcome(res))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if n := len(report.Failed()); n > 0 {
+		return fmt.Errorf("%d of %d retries failed", n, len(report.Results))
+	}
+	return nil
+}
+
+func printGroups(gs []panda.FailureGroup, selected func(*panda.FailureGroup) bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tPROFILE\tCLASS\tMESSAGE")
+	for i := range gs {
+		g := &gs[i]
+		mark := ""
+		if selected != nil && selected(g) {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "%d%s\t%s\t%s\t%s\n", len(g.Encodings), mark, g.ProfileName, g.ErrorClass, g.ErrorMessage)
+	}
+	w.Flush()
+}
+
+func outcome(res panda.BatchResult) string {
+	switch {
+	case res.Err != nil:
+		return res.Err.Error()
+	case res.Skipped:
+		return "skipped"
+	case res.DryRun:
+		return "dry run"
+	case res.CheckpointErr != nil:
+		return "retried, not checkpointed: " + res.CheckpointErr.Error()
+	}
+	return "retried"
+}
@@ -0,0 +1,209 @@
+package panda
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Placeholders substituted by Panda in a profile's custom command
+const (
+	PlaceholderInputFile       = "$input_file$"
+	PlaceholderOutputFile      = "$output_file$"
+	PlaceholderAudioBitrate    = "$audio_bitrate$"
+	PlaceholderVideoBitrate    = "$video_bitrate$"
+	PlaceholderFilters         = "$filters$"
+	PlaceholderWidth           = "$width$"
+	PlaceholderHeight          = "$height$"
+	PlaceholderFps             = "$fps$"
+	PlaceholderAudioSampleRate = "$audio_sample_rate$"
+	PlaceholderAudioChannels   = "$audio_channels$"
+)
+
+// sampleValues holds every known placeholder with the value PreviewCommand uses
+// for it when none is given
+var sampleValues = map[string]string{
+	PlaceholderInputFile:       "/tmp/input.mp4",
+	PlaceholderOutputFile:      "/tmp/output.mp4",
+	PlaceholderAudioBitrate:    "-b:a 128k",
+	PlaceholderVideoBitrate:    "-b:v 500k",
+	PlaceholderFilters:         "-vf scale=640:360",
+	PlaceholderWidth:           "640",
+	PlaceholderHeight:          "360",
+	PlaceholderFps:             "29.97",
+	PlaceholderAudioSampleRate: "44100",
+	PlaceholderAudioChannels:   "2",
+}
+
+// SampleValues returns a copy of the values PreviewCommand uses for placeholders
+// without a given value, keyed by placeholder
+func SampleValues() map[string]string {
+	vs := make(map[string]string, len(sampleValues))
+	for p, v := range sampleValues {
+		vs[p] = v
+	}
+	return vs
+}
+
+// FFmpegCommand builds a custom command for Profile.Command from typed options.
+// Empty options are left out.
+type FFmpegCommand struct {
+	// Input defaults to $input_file$ and Output to $output_file$
+	Input  string
+	Output string
+	// AudioCodec and VideoCodec are passed as -c:a and -c:v
+	AudioCodec string
+	VideoCodec string
+	// AudioBitrate and VideoBitrate are passed as -b:a and -b:v, e.g. "128k"
+	AudioBitrate string
+	VideoBitrate string
+	// ProfileBitrates uses the bitrates of the profile through the $audio_bitrate$
+	// and $video_bitrate$ placeholders instead
+	ProfileBitrates bool
+	// Preset is passed as -preset
+	Preset string
+	// Filters are joined into a single -vf option
+	Filters []string
+	// ProfileFilters uses the filters derived from the profile's resolution and
+	// aspect mode through the $filters$ placeholder instead
+	ProfileFilters bool
+	// Args are added before the output, as they are
+	Args []string
+	// Overwrite adds -y
+	Overwrite bool
+}
+
+func (c *FFmpegCommand) args() []string {
+	in, out := c.Input, c.Output
+	if in == "" {
+		in = PlaceholderInputFile
+	}
+	if out == "" {
+		out = PlaceholderOutputFile
+	}
+	args := []string{"ffmpeg", "-i", quoteArg(in)}
+	opt := func(name, v string) {
+		if v != "" {
+			args = append(args, name, quoteArg(v))
+		}
+	}
+	opt("-c:a", c.AudioCodec)
+	if c.ProfileBitrates {
+		args = append(args, PlaceholderAudioBitrate)
+	} else {
+		opt("-b:a", c.AudioBitrate)
+	}
+	opt("-c:v", c.VideoCodec)
+	if c.ProfileBitrates {
+		args = append(args, PlaceholderVideoBitrate)
+	} else {
+		opt("-b:v", c.VideoBitrate)
+	}
+	opt("-preset", c.Preset)
+	if c.ProfileFilters {
+		args = append(args, PlaceholderFilters)
+	} else {
+		opt("-vf", strings.Join(c.Filters, ","))
+	}
+	for _, a := range c.Args {
+		args = append(args, quoteArg(a))
+	}
+	if c.Overwrite {
+		args = append(args, "-y")
+	}
+	return append(args, quoteArg(out))
+}
+
+// String returns the command without validating it
+func (c *FFmpegCommand) String() string {
+	return strings.Join(c.args(), " ")
+}
+
+// Build returns the command, ready to be set as Profile.Command
+func (c *FFmpegCommand) Build() (string, error) {
+	if c.ProfileBitrates && (c.AudioBitrate != "" || c.VideoBitrate != "") {
+		return "", errors.New("panda: command has both explicit and profile bitrates")
+	}
+	if c.ProfileFilters && len(c.Filters) > 0 {
+		return "", errors.New("panda: command has both explicit and profile filters")
+	}
+	s := c.String()
+	if err := ValidateCommand(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// quoteArg quotes a for the shell unless it consists of characters which need
+// no quoting. Placeholders are substituted by Panda before the shell runs.
+func quoteArg(a string) string {
+	if a == "" {
+		return "''"
+	}
+	if strings.IndexFunc(a, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("_-+=:,./@%$", r))
+	}) < 0 {
+		return a
+	}
+	return "'" + strings.Replace(a, "'", `'\''`, -1) + "'"
+}
+
+// ValidateCommand checks a custom command. Commands may span several lines, each
+// of which must have balanced quotes. Every $name$ outside single quotes must be
+// a known placeholder and the command must read $input_file$ and write
+// $output_file$.
+func ValidateCommand(cmd string) error {
+	if strings.TrimSpace(cmd) == "" {
+		return errors.New("panda: command is empty")
+	}
+	for n, line := range strings.Split(cmd, "\n") {
+		var quote rune
+		for i := 0; i < len(line); i++ {
+			switch ch := rune(line[i]); {
+			case quote == 0 && (ch == '\'' || ch == '"'):
+				quote = ch
+			case ch == quote:
+				quote = 0
+			case ch == '\\' && quote != '\'':
+				i++
+			case ch == '$' && quote != '\'':
+				end := strings.IndexByte(line[i+1:], '$')
+				if end < 0 {
+					return fmt.Errorf("panda: command line %d: unterminated placeholder at %q", n+1, line[i:])
+				}
+				p := line[i : i+end+2]
+				if _, ok := sampleValues[p]; !ok {
+					return fmt.Errorf("panda: command line %d: unknown placeholder %s", n+1, p)
+				}
+				i += end + 1
+			}
+		}
+		if quote != 0 {
+			return fmt.Errorf("panda: command line %d: unbalanced %c quote", n+1, quote)
+		}
+	}
+	for _, p := range []string{PlaceholderInputFile, PlaceholderOutputFile} {
+		if !strings.Contains(cmd, p) {
+			return fmt.Errorf("panda: command does not use %s", p)
+		}
+	}
+	return nil
+}
+
+// PreviewCommand validates the command and substitutes its placeholders with the
+// given values, or with SampleValues() for placeholders without one, so that it can
+// be reviewed before it is set on a profile
+func PreviewCommand(cmd string, values map[string]string) (string, error) {
+	if err := ValidateCommand(cmd); err != nil {
+		return "", err
+	}
+	pairs := make([]string, 0, 2*len(sampleValues))
+	for p, v := range sampleValues {
+		if s, ok := values[p]; ok {
+			v = s
+		}
+		pairs = append(pairs, p, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(cmd), nil
+}
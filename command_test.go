@@ -0,0 +1,106 @@
+package panda
+
+import "testing"
+
+func TestFFmpegCommandBuild(t *testing.T) {
+	cases := []struct {
+		c     FFmpegCommand
+		exp   string
+		valid bool
+	}{
+		{
+			FFmpegCommand{AudioCodec: "libfaac", VideoCodec: "libx264", ProfileBitrates: true,
+				Preset: "medium", ProfileFilters: true, Overwrite: true},
+			"ffmpeg -i $input_file$ -c:a libfaac $audio_bitrate$ -c:v libx264 $video_bitrate$ -preset medium $filters$ -y $output_file$",
+			true,
+		},
+		{
+			FFmpegCommand{VideoBitrate: "800k", Filters: []string{"scale=640:-2", "drawtext=text='Panda Demo'"},
+				Args: []string{"-movflags", "+faststart"}},
+			`ffmpeg -i $input_file$ -b:v 800k -vf 'scale=640:-2,drawtext=text='\''Panda Demo'\''' -movflags +faststart $output_file$`,
+			true,
+		},
+		{
+			FFmpegCommand{VideoBitrate: "800k", ProfileBitrates: true},
+			"",
+			false,
+		},
+		{
+			FFmpegCommand{Filters: []string{"yadif"}, ProfileFilters: true},
+			"",
+			false,
+		},
+		{
+			FFmpegCommand{Output: "/tmp/out.mp4"},
+			"",
+			false,
+		},
+		{
+			FFmpegCommand{Args: []string{"-metadata", "title=$title$"}},
+			"",
+			false,
+		},
+	}
+	for i, c := range cases {
+		s, err := c.c.Build()
+		if (err == nil) != c.valid {
+			t.Errorf("want valid=%t; got err=%v (i=%d)", c.valid, err, i)
+		}
+		if s != c.exp {
+			t.Errorf("want %q; got %q (i=%d)", c.exp, s, i)
+		}
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	cases := []struct {
+		cmd   string
+		valid bool
+	}{
+		{"ffmpeg -i $input_file$ $filters$ -y $output_file$", true},
+		{"ffmpeg -i $input_file$ -pass 1 -f null /dev/null\nffmpeg -i $input_file$ -pass 2 $output_file$", true},
+		{`ffmpeg -i $input_file$ -metadata "title=it's" $output_file$`, true},
+		{`ffmpeg -i $input_file$ -metadata title=it\'s $output_file$`, true},
+		{`ffmpeg -i $input_file$ -metadata 'comment=costs $5' $output_file$`, true},
+		{`ffmpeg -i $input_file$ -metadata "comment=costs $5" $output_file$`, false},
+		{"", false},
+		{"ffmpeg -i $input_file$ $output_file", false},
+		{"ffmpeg -i $input_file$ $video_bitrat$ $output_file$", false},
+		{"ffmpeg -i $input_file$ -vf 'scale=640:360 $output_file$", false},
+		{"ffmpeg -i $input_file$ -y out.mp4", false},
+	}
+	for i, c := range cases {
+		if err := ValidateCommand(c.cmd); (err == nil) != c.valid {
+			t.Errorf("want valid=%t; got err=%v (i=%d)", c.valid, err, i)
+		}
+	}
+}
+
+func TestPreviewCommand(t *testing.T) {
+	cmd := "ffmpeg -i $input_file$ $video_bitrate$ $filters$ -y $output_file$"
+	s, err := PreviewCommand(cmd, map[string]string{PlaceholderInputFile: "movie.mov"})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if exp := "ffmpeg -i movie.mov -b:v 500k -vf scale=640:360 -y /tmp/output.mp4"; s != exp {
+		t.Errorf("want %q; got %q", exp, s)
+	}
+	if _, err = PreviewCommand("ffmpeg $input_file$", nil); err == nil {
+		t.Error("want error for invalid command")
+	}
+}
+
+func TestSampleValues(t *testing.T) {
+	vs := SampleValues()
+	if vs[PlaceholderWidth] != "640" {
+		t.Errorf("want width=640; got %q", vs[PlaceholderWidth])
+	}
+	vs[PlaceholderWidth] = "1920"
+	vs["$title$"] = "x"
+	if w := SampleValues()[PlaceholderWidth]; w != "640" {
+		t.Errorf("want samples unchanged; got width=%q", w)
+	}
+	if err := ValidateCommand("ffmpeg -i $input_file$ $title$ $output_file$"); err == nil {
+		t.Error("want $title$ to stay unknown")
+	}
+}
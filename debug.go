@@ -0,0 +1,78 @@
+package panda
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxLoggedBody is the number of response body bytes included in debug logs
+const maxLoggedBody = 1024
+
+const redacted = "REDACTED"
+
+// redactedParams are never written to the debug log
+var redactedParams = []string{"signature", "access_key", "token", "encryption_key"}
+
+// redact returns a copy of v with the values of all sensitive parameters replaced
+func redact(v url.Values) url.Values {
+	r := make(url.Values, len(v))
+	for k, vs := range v {
+		r[k] = vs
+	}
+	for _, k := range redactedParams {
+		if _, ok := r[k]; ok {
+			r[k] = []string{redacted}
+		}
+	}
+	return r
+}
+
+// redactQuery redacts sensitive parameters of an encoded query string
+func redactQuery(q string) string {
+	parts := strings.Split(q, "&")
+	for i, p := range parts {
+		k := p
+		if j := strings.IndexByte(p, '='); j >= 0 {
+			k = p[:j]
+		}
+		for _, s := range redactedParams {
+			if k == s {
+				parts[i] = k + "=" + redacted
+			}
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// redactError redacts the query of the URL of transport errors, which holds the
+// access key and signature
+func redactError(err error) error {
+	ue, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u := ue.URL
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		u = u[:i+1] + redactQuery(u[i+1:])
+	}
+	return &url.Error{Op: ue.Op, URL: u, Err: ue.Err}
+}
+
+// redactedFields matches sensitive string fields of JSON response bodies
+var redactedFields = regexp.MustCompile(`("(?:encryption_key|access_key|secret_key|token|signature)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactBody masks sensitive fields of a response body and truncates it
+func redactBody(b []byte) string {
+	b = redactedFields.ReplaceAll(b, []byte(`$1"`+redacted+`"`))
+	if len(b) <= maxLoggedBody {
+		return string(b)
+	}
+	return string(b[:maxLoggedBody]) + "..."
+}
+
+func (cl *Client) debug(msg string, args ...interface{}) {
+	if cl.Logger != nil {
+		cl.Logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,74 @@
+package panda
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		mustWrite(w, []byte(`{"error":"NotAuthorized","message":"Signatures do not match"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	m.Client.Options.AccessKey = "secret-access-key"
+	buf := &bytes.Buffer{}
+	m.Client.Logger = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	_, err := m.NewProfile(&NewProfileRequest{Name: "h264", EncryptionKey: "secret-encryption-key"})
+	if err == nil {
+		t.Fatal("want err!=nil; got nil")
+	}
+	out := buf.String()
+	for _, s := range []string{"secret-access-key", "secret-encryption-key"} {
+		if strings.Contains(out, s) {
+			t.Errorf("want %q to be redacted; got %s", s, out)
+		}
+	}
+	for _, s := range []string{"string to sign", "POST", "/v2/profiles.json", "name=h264", "status=401", "Signatures do not match", "signature=REDACTED"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("want log to contain %q; got %s", s, out)
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	in := "access_key=abc&cloud_id=1&timestamp=now&token=xyz"
+	exp := "access_key=REDACTED&cloud_id=1&timestamp=now&token=REDACTED"
+	if res := redactQuery(in); res != exp {
+		t.Errorf("want %s; got %s", exp, res)
+	}
+}
+
+func TestDebugLoggerSecrets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mustWrite(w, []byte(`{"id":"p1","encryption_key": "secret-encryption-key","secret_key":"secret-key"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	m.Client.Options.AccessKey = "secret-access-key"
+	buf := &bytes.Buffer{}
+	m.Client.Logger = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := m.Profile("p1"); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	ts.Close()
+	if _, err := m.Profile("p1"); err == nil {
+		t.Fatal("want err!=nil for closed server")
+	}
+	out := buf.String()
+	for _, s := range []string{"secret-access-key", "secret-encryption-key", "secret-key"} {
+		if strings.Contains(out, s) {
+			t.Errorf("want %q to be redacted; got %s", s, out)
+		}
+	}
+	for _, s := range []string{"request failed", "access_key=REDACTED", "signature=REDACTED", `\"encryption_key\": \"REDACTED\"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("want log to contain %q; got %s", s, out)
+		}
+	}
+}
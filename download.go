@@ -0,0 +1,325 @@
+package panda
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OutputFile is a single file produced by an encoding
+type OutputFile struct {
+	// Name is the file name relative to the cloud's bucket
+	Name string
+	URL  string
+}
+
+// OutputFiles returns the public URLs of the files of the given encoding. Multi-file
+// outputs such as HLS list all of their files in Encoding.Files, otherwise the main
+// file is named after the encoding's Path and Extname. For clouds with
+// S3PrivateAccess use Manager.EncodingURLs with a URLSigner instead.
+func OutputFiles(c *Cloud, e *Encoding) []OutputFile {
+	names := encodingFiles(e)
+	base := cloudBaseURL(c)
+	fs := make([]OutputFile, len(names))
+	for i, name := range names {
+		fs[i] = OutputFile{Name: name, URL: base + name}
+	}
+	return fs
+}
+
+func encodingFiles(e *Encoding) []string {
+	if len(e.Files) == 0 {
+		return []string{e.Path + e.Extname}
+	}
+	return e.Files
+}
+
+// Progress reports how much of a file has been downloaded
+type Progress struct {
+	File    OutputFile
+	Written int64
+	// Total is -1 if the size of the file is not known
+	Total int64
+	Done  bool
+}
+
+// ChecksumError is returned when a downloaded file does not match the checksum
+// reported by the storage
+type ChecksumError struct {
+	File     OutputFile
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("panda: checksum mismatch for %s: expected %s, got %s", e.File.Name, e.Expected, e.Actual)
+}
+
+// Downloader fetches output files. Files are verified against the MD5 checksum
+// reported in the Content-MD5 header or, for single part uploads, the S3 ETag.
+type Downloader struct {
+	HTTPClient *http.Client
+	// Workers is the number of concurrent downloads, defaults to 4
+	Workers int
+	// Progress is called from the downloading goroutines as data arrives
+	Progress func(Progress)
+}
+
+func (d *Downloader) httpclient() *http.Client {
+	if d.HTTPClient != nil {
+		return d.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// DownloadEncoding downloads all files of the encoding stored in a public cloud into dir
+func (d *Downloader) DownloadEncoding(c *Cloud, e *Encoding, dir string) error {
+	return d.Download(OutputFiles(c, e), dir)
+}
+
+// Download downloads the given files into dir, keeping their names. Files are first
+// written with a .part suffix and renamed once complete and verified. Downloads of
+// existing .part files are resumed and files which already exist are skipped. A
+// .part file failing verification is removed. Names which would lead outside dir
+// are rejected.
+func (d *Downloader) Download(files []OutputFile, dir string) error {
+	return d.each(files, func(f OutputFile) error {
+		path, err := localPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		part := path + ".part"
+		if err := d.resume(f, part); err != nil {
+			if _, ok := err.(*ChecksumError); ok {
+				// a corrupt .part file must not be resumed by the next run
+				os.Remove(part)
+			}
+			return err
+		}
+		return os.Rename(part, path)
+	})
+}
+
+// localPath returns the path of the file with the given name inside dir. Names
+// come from the server, so absolute names and names leading outside dir are rejected.
+func localPath(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" ||
+		clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("panda: invalid output file name %q", name)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// DownloadTo downloads the given files into writers created by create. Writers are
+// closed once their file has been written.
+func (d *Downloader) DownloadTo(files []OutputFile, create func(f OutputFile) (io.WriteCloser, error)) error {
+	return d.each(files, func(f OutputFile) error {
+		w, err := create(f)
+		if err != nil {
+			return err
+		}
+		resp, err := d.get(f, 0)
+		if err != nil {
+			w.Close()
+			return err
+		}
+		defer resp.Body.Close()
+		err = d.copy(f, w, resp, md5.New(), 0)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
+
+func (d *Downloader) each(files []OutputFile, fn func(OutputFile) error) error {
+	workers := d.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	ch := make(chan OutputFile)
+	errs := make(chan error, len(files))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range ch {
+				if err := fn(f); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	for _, f := range files {
+		ch <- f
+	}
+	close(ch)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (d *Downloader) get(f OutputFile, offset int64) (*http.Response, error) {
+	return d.request("GET", f, offset)
+}
+
+func (d *Downloader) request(method string, f OutputFile, offset int64) (*http.Response, error) {
+	req, err := http.NewRequest(method, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := d.httpclient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		return resp, nil
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("panda: downloading %s: %s", f.URL, resp.Status)
+}
+
+func (d *Downloader) resume(f OutputFile, part string) error {
+	file, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	h := md5.New()
+	offset, err := io.Copy(h, file)
+	if err != nil {
+		return err
+	}
+	resp, err := d.get(f, offset)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		complete, err := d.complete(f, resp.Header, offset, h)
+		if err != nil || complete {
+			return err
+		}
+		if resp, err = d.get(f, 0); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		if err = file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		h, offset = md5.New(), 0
+	}
+	if err = d.copy(f, file, resp, h, offset); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+func (d *Downloader) copy(f OutputFile, w io.Writer, resp *http.Response, h hash.Hash, offset int64) error {
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	pw := &progressWriter{d: d, p: Progress{File: f, Written: offset, Total: total}}
+	if _, err := io.Copy(io.MultiWriter(w, h, pw), resp.Body); err != nil {
+		return err
+	}
+	if err := verify(f, resp.Header, h); err != nil {
+		return err
+	}
+	pw.p.Done = true
+	pw.report()
+	return nil
+}
+
+// complete reports whether a .part file of the given size, which the server
+// answered with 416 for, holds the whole file. 416 responses carry no checksum, so
+// it is fetched with a HEAD request. Without a checksum the file cannot be trusted
+// and false is returned, so that it is downloaded again.
+func (d *Downloader) complete(f OutputFile, header http.Header, size int64, h hash.Hash) (bool, error) {
+	if cr := header.Get("Content-Range"); strings.HasPrefix(cr, "bytes */") {
+		if n, err := strconv.ParseInt(cr[len("bytes */"):], 10, 64); err == nil && n != size {
+			return false, nil
+		}
+	}
+	resp, err := d.request("HEAD", f, 0)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if checksum(resp.Header) == "" {
+		return false, nil
+	}
+	return true, verify(f, resp.Header, h)
+}
+
+// checksum returns the hex MD5 reported in Content-MD5 or a single part ETag, or
+// an empty string if there is none. Ranged responses carry the ETag of the whole
+// object, but a Content-MD5 of the range only.
+func checksum(header http.Header) string {
+	if cm := header.Get("Content-MD5"); cm != "" && header.Get("Content-Range") == "" {
+		if b, err := base64.StdEncoding.DecodeString(cm); err == nil {
+			return hex.EncodeToString(b)
+		}
+	}
+	etag := strings.Trim(header.Get("ETag"), `"`)
+	if len(etag) == 32 && !strings.Contains(etag, "-") {
+		if _, err := hex.DecodeString(etag); err == nil {
+			return etag
+		}
+	}
+	return ""
+}
+
+// verify compares the hash, which must cover the whole file, with the checksum
+// in the header if there is one
+func verify(f OutputFile, header http.Header, h hash.Hash) error {
+	exp := checksum(header)
+	if actual := hex.EncodeToString(h.Sum(nil)); exp != "" && exp != actual {
+		return &ChecksumError{File: f, Expected: exp, Actual: actual}
+	}
+	return nil
+}
+
+type progressWriter struct {
+	d *Downloader
+	p Progress
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	pw.p.Written += int64(len(b))
+	pw.report()
+	return len(b), nil
+}
+
+func (pw *progressWriter) report() {
+	if pw.d.Progress != nil {
+		pw.d.Progress(pw.p)
+	}
+}
@@ -0,0 +1,190 @@
+package panda
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func newFileServer(files map[string]string, ranges *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		content, ok := files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if rg := r.Header.Get("Range"); rg != "" {
+			mu.Lock()
+			*ranges = append(*ranges, name+":"+rg)
+			mu.Unlock()
+		}
+		sum := md5.Sum([]byte(content))
+		etag := hex.EncodeToString(sum[:])
+		if name == "corrupt.ts" {
+			etag = strings.Repeat("0", 32)
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+		http.ServeContent(w, r, name, time.Time{}, strings.NewReader(content))
+	}))
+}
+
+func TestDownloadEncoding(t *testing.T) {
+	files := map[string]string{
+		"e1.m3u8":    "#EXTM3U\n",
+		"e1_0001.ts": strings.Repeat("a", 4096),
+		"e1_0002.ts": strings.Repeat("b", 4096),
+	}
+	var ranges []string
+	ts := newFileServer(files, &ranges)
+	defer ts.Close()
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "e1_0002.ts.part"), []byte(strings.Repeat("b", 1000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	done := map[string]bool{}
+	d := &Downloader{Progress: func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Done {
+			done[p.File.Name] = p.Written == p.Total
+		}
+	}}
+	c := &Cloud{URL: ts.URL + "/"}
+	e := &Encoding{Path: "e1", Extname: ".m3u8", Files: []string{"e1.m3u8", "e1_0001.ts", "e1_0002.ts"}}
+	if err := d.DownloadEncoding(c, e, dir); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	for name, content := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("want %s to be downloaded completely; got %d bytes", name, len(b))
+		}
+		if !done[name] {
+			t.Errorf("want completed progress for %s", name)
+		}
+	}
+	if len(ranges) != 1 || ranges[0] != "e1_0002.ts:bytes=1000-" {
+		t.Errorf("want e1_0002.ts to be resumed; got ranges %v", ranges)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "e1_0002.ts.part")); !os.IsNotExist(err) {
+		t.Errorf("want .part file to be renamed; got %v", err)
+	}
+}
+
+func TestDownloadTo(t *testing.T) {
+	files := map[string]string{"ok.mp4": "video", "corrupt.ts": "segment"}
+	var ranges []string
+	ts := newFileServer(files, &ranges)
+	defer ts.Close()
+	bufs := map[string]*bytes.Buffer{}
+	create := func(f OutputFile) (io.WriteCloser, error) {
+		bufs[f.Name] = &bytes.Buffer{}
+		return nopCloser{bufs[f.Name]}, nil
+	}
+	d := &Downloader{}
+	err := d.DownloadTo([]OutputFile{{Name: "ok.mp4", URL: ts.URL + "/ok.mp4"}}, create)
+	if err != nil || bufs["ok.mp4"].String() != "video" {
+		t.Errorf("want ok.mp4 to be downloaded; got %q (err=%v)", bufs["ok.mp4"], err)
+	}
+	err = d.DownloadTo([]OutputFile{{Name: "corrupt.ts", URL: ts.URL + "/corrupt.ts"}}, create)
+	if _, ok := err.(*ChecksumError); !ok {
+		t.Errorf("want ChecksumError; got %v", err)
+	}
+}
+
+func TestDownloadCorruptPart(t *testing.T) {
+	content := strings.Repeat("a", 8)
+	withETag := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "bytes=8-" {
+			// like S3, answer unsatisfiable ranges without a checksum
+			w.Header().Set("Content-Range", "bytes */8")
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if withETag {
+			sum := md5.Sum([]byte(content))
+			w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		}
+		http.ServeContent(w, r, "f.ts", time.Time{}, strings.NewReader(content))
+	}))
+	defer ts.Close()
+	files := []OutputFile{{Name: "f.ts", URL: ts.URL + "/f.ts"}}
+	part := func(dir, s string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "f.ts.part"), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		part     string
+		withETag bool
+		failing  bool
+	}{
+		{"bbbbb", true, true},
+		{"bbbbbaaa", true, true},
+		{"bbbbbaaa", false, false},
+	}
+	for i, c := range cases {
+		dir := t.TempDir()
+		part(dir, c.part)
+		withETag = c.withETag
+		d := &Downloader{}
+		err := d.Download(files, dir)
+		if _, ok := err.(*ChecksumError); ok != c.failing {
+			t.Errorf("want ChecksumError=%t; got %v (i=%d)", c.failing, err, i)
+		}
+		if c.failing {
+			if _, err := os.Stat(filepath.Join(dir, "f.ts.part")); !os.IsNotExist(err) {
+				t.Errorf("want corrupt .part file to be removed; got %v (i=%d)", err, i)
+			}
+			if err = d.Download(files, dir); err != nil {
+				t.Fatalf("want err=nil on the second run; got %v (i=%d)", err, i)
+			}
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, "f.ts"))
+		if err != nil || string(b) != content {
+			t.Errorf("want f.ts=%q; got %q (err=%v, i=%d)", content, b, err, i)
+		}
+	}
+}
+
+func TestDownloadRejectsEscapingNames(t *testing.T) {
+	ts := newFileServer(map[string]string{"x": "x"}, new([]string))
+	defer ts.Close()
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	for _, name := range []string{"../escaped.txt", "a/../../escaped.txt", "/etc/escaped.txt", ".."} {
+		err := (&Downloader{}).Download([]OutputFile{{Name: name, URL: ts.URL + "/x"}}, dir)
+		if err == nil {
+			t.Errorf("want error for %q", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); !os.IsNotExist(err) {
+		t.Errorf("want no file outside dir; got %v", err)
+	}
+	if p, err := localPath(dir, "hls/a/../seg.ts"); err != nil || p != filepath.Join(dir, "hls", "seg.ts") {
+		t.Errorf("want nested name to be cleaned; got %q (err=%v)", p, err)
+	}
+}
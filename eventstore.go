@@ -0,0 +1,127 @@
+package panda
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventStore records processed events so that redelivered notifications are
+// recognized and processed events can be replayed
+type EventStore interface {
+	// Seen reports whether an event with the given key has been saved
+	Seen(key string) (bool, error)
+	// Save records the event as processed
+	Save(e *Event) error
+	// Events returns all saved events received at or after since, in the order
+	// they were saved
+	Events(since time.Time) ([]*Event, error)
+}
+
+// MemoryStore is an EventStore keeping events in memory
+type MemoryStore struct {
+	mu     sync.Mutex
+	keys   map[string]bool
+	events []*Event
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]bool{}}
+}
+
+func (s *MemoryStore) Seen(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+func (s *MemoryStore) Save(e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.keys[e.Key()] {
+		s.keys[e.Key()] = true
+		s.events = append(s.events, e)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Events(since time.Time) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var es []*Event
+	for _, e := range s.events {
+		if !e.Received.Before(since) {
+			es = append(es, e)
+		}
+	}
+	return es, nil
+}
+
+// EventProcessor passes every event to its Handler at most once, as identified by
+// its Key; events without a timestamp are passed on every delivery. Events are saved
+// in the Store only after the handler succeeded, so failed events are processed
+// again when Panda redelivers them. EventProcessor is an http.Handler and can be
+// used in place of a WebhookHandler.
+type EventProcessor struct {
+	Handler *WebhookHandler
+	Store   EventStore
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+// Process passes the event to the handler unless it has already been processed or
+// is being processed right now. It reports whether the handler was called.
+func (p *EventProcessor) Process(e *Event) (bool, error) {
+	key := e.Key()
+	p.mu.Lock()
+	if p.inFlight == nil {
+		p.inFlight = map[string]bool{}
+	}
+	if p.inFlight[key] {
+		p.mu.Unlock()
+		return false, nil
+	}
+	p.inFlight[key] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.inFlight, key)
+		p.mu.Unlock()
+	}()
+
+	seen, err := p.Store.Seen(key)
+	if err != nil || seen {
+		return false, err
+	}
+	if err = p.Handler.Dispatch(e); err != nil {
+		return true, err
+	}
+	return true, p.Store.Save(e)
+}
+
+func (p *EventProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveEvent(w, r, func(e *Event) error {
+		_, err := p.Process(e)
+		return err
+	})
+}
+
+// Replay passes all stored events received at or after since to h, or to the
+// processor's Handler if h is nil, in the order they were originally processed
+func (p *EventProcessor) Replay(since time.Time, h *WebhookHandler) error {
+	if h == nil {
+		h = p.Handler
+	}
+	es, err := p.Store.Events(since)
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		if err := h.Dispatch(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package panda_test
 
 import (
 	"fmt"
+	"log"
+	"time"
 
 	panda "github.com/pandastream/go-panda"
 )
@@ -185,3 +187,29 @@ func ExampleManager_videos() {
 	}
 	fmt.Println(videos)
 }
+
+func ExampleClient_use() {
+	cl := &panda.Client{
+		Host: panda.HostGCE,
+		Options: &panda.ClientOptions{
+			AccessKey: "access_key",
+			SecretKey: "secret_key",
+			CloudID:   "cloud_id",
+		},
+	}
+	cl.Use(func(next panda.CallHandler) panda.CallHandler {
+		return func(c *panda.Call) (*panda.Response, error) {
+			start := time.Now()
+			resp, err := next(c)
+			log.Printf("%s %s %s took %v", c.Operation, c.Method, c.Path, time.Since(start))
+			return resp, err
+		}
+	})
+
+	m := panda.Manager{cl}
+	profiles, err := m.Profiles(nil)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(profiles)
+}
@@ -0,0 +1,260 @@
+package panda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the encoding of exported profiles
+type Format string
+
+const (
+	FormatJSON = Format("json")
+	FormatYAML = Format("yaml")
+)
+
+// ConflictStrategy decides what ImportProfiles does with a profile whose name
+// already exists in the cloud
+type ConflictStrategy uint8
+
+const (
+	// ConflictSkip leaves the existing profile unchanged
+	ConflictSkip = ConflictStrategy(0)
+	// ConflictOverwrite updates the existing profile with the imported settings
+	ConflictOverwrite = ConflictStrategy(1)
+	// ConflictRename creates the profile under a new name, e.g. h264_2
+	ConflictRename = ConflictStrategy(2)
+)
+
+// profileExport is the document written by ExportProfiles. Profiles are kept as
+// maps, so that only the fields present in the document are used on import.
+type profileExport struct {
+	Profiles []map[string]interface{} `json:"profiles" yaml:"profiles"`
+}
+
+// ImportResult describes what happened to a single imported profile
+type ImportResult struct {
+	Name string
+	// ID of the created or updated profile, empty if skipped or failed
+	ID string
+	// Conflict is set if a profile with the same name existed
+	Conflict bool
+	// Action is one of "created", "skipped", "overwritten" or "renamed"
+	Action string
+	// NewName is the name a renamed profile was created with
+	NewName string
+	Err     error
+}
+
+// ImportReport lists the results of ImportProfiles in the order of the document
+type ImportReport struct {
+	Results []ImportResult
+}
+
+// Conflicts returns the names of the imported profiles which already existed
+func (r *ImportReport) Conflicts() []string {
+	var names []string
+	for _, res := range r.Results {
+		if res.Conflict {
+			names = append(names, res.Name)
+		}
+	}
+	return names
+}
+
+// Failed returns the results of profiles which could not be imported
+func (r *ImportReport) Failed() []ImportResult {
+	var failed []ImportResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// AllProfiles gets the expanded profiles of the current cloud from all pages of
+// the listing, until a page has no new profiles
+func (m *Manager) AllProfiles() ([]Profile, error) {
+	req := ProfileRequest{Expand: true, PerPage: defaultPerPage}
+	return allPages(func(page int) ([]Profile, error) {
+		req.Page = page
+		return m.Profiles(&req)
+	}, func(p *Profile) string { return p.ID })
+}
+
+// ExportProfiles writes all profiles of the current cloud to w. Profiles are
+// sorted by name and contain every field which can be set when creating a
+// profile, zero values included, so that the output is suitable for version
+// control and overwriting a profile on import resets the fields unset in the
+// export. Encryption keys are secrets and are left out, an overwritten profile
+// keeps its key.
+func (m *Manager) ExportProfiles(w io.Writer, f Format) error {
+	ps, err := m.AllProfiles()
+	if err != nil {
+		return err
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	doc := profileExport{Profiles: make([]map[string]interface{}, 0, len(ps))}
+	for i := range ps {
+		pr, err := ps[i].NewProfileRequest()
+		if err != nil {
+			return err
+		}
+		doc.Profiles = append(doc.Profiles, exportFields(pr))
+	}
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err = enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("panda: unknown export format %q", f)
+}
+
+// exportFields returns the fields of pr by their JSON names, except for the
+// encryption key
+func exportFields(pr *NewProfileRequest) map[string]interface{} {
+	v := reflect.ValueOf(pr).Elem()
+	fields := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "encryption_key" {
+			continue
+		}
+		fields[name] = v.Field(i).Interface()
+	}
+	return fields
+}
+
+func readProfiles(r io.Reader, f Format) ([]map[string]interface{}, error) {
+	var doc profileExport
+	switch f {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("panda: unknown export format %q", f)
+	}
+	for i, p := range doc.Profiles {
+		if name, _ := p["name"].(string); name == "" {
+			return nil, fmt.Errorf("panda: imported profile %d has no name", i)
+		}
+	}
+	return doc.Profiles, nil
+}
+
+// ImportProfiles creates the profiles written by ExportProfiles in the current
+// cloud. Profiles are matched with existing ones by name and conflicts are
+// resolved with the given strategy. Failures of single profiles are recorded in
+// the report; an error is returned only if the document or the existing
+// profiles could not be read. Once the manager's context is done the profiles
+// left fail with its error without calling Panda.
+func (m *Manager) ImportProfiles(r io.Reader, f Format, s ConflictStrategy) (*ImportReport, error) {
+	doc, err := readProfiles(r, f)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := m.AllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*Profile, len(ps))
+	for i := range ps {
+		existing[ps[i].Name] = &ps[i]
+	}
+	rep := &ImportReport{Results: make([]ImportResult, 0, len(doc))}
+	for _, fields := range doc {
+		name := fields["name"].(string)
+		res := ImportResult{Name: name}
+		cur, conflict := existing[name]
+		res.Conflict = conflict
+		var p *Profile
+		switch {
+		case m.Client.context().Err() != nil:
+			res.Err = m.Client.context().Err()
+		case !conflict:
+			res.Action = "created"
+			p, res.Err = m.importProfile(fields)
+		case s == ConflictSkip:
+			res.Action = "skipped"
+		case s == ConflictOverwrite:
+			res.Action = "overwritten"
+			p, res.Err = m.overwriteProfile(cur.ID, fields)
+		case s == ConflictRename:
+			res.Action = "renamed"
+			res.NewName = freeName(name, existing)
+			fields["name"] = res.NewName
+			p, res.Err = m.importProfile(fields)
+		default:
+			res.Err = errors.New("panda: unknown conflict strategy")
+		}
+		if p != nil {
+			res.ID = p.ID
+			existing[p.Name] = p
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	return rep, nil
+}
+
+func (m *Manager) importProfile(fields map[string]interface{}) (*Profile, error) {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	pr := new(NewProfileRequest)
+	if err = json.Unmarshal(b, pr); err != nil {
+		return nil, err
+	}
+	return m.NewProfile(pr)
+}
+
+// overwriteProfile updates exactly the fields present in the imported document
+func (m *Manager) overwriteProfile(id string, fields map[string]interface{}) (*Profile, error) {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	p := new(Profile)
+	if err = json.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	p.ID = id
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return m.UpdateProfile(p, names...)
+}
+
+// freeName returns name with the lowest numeric suffix not used by a profile
+func freeName(name string, existing map[string]*Profile) string {
+	for i := 2; ; i++ {
+		n := name + "_" + strconv.Itoa(i)
+		if _, ok := existing[n]; !ok {
+			return n
+		}
+	}
+}
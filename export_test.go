@@ -0,0 +1,202 @@
+package panda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// profileCloud serves the profile endpoints from memory
+type profileCloud struct {
+	mu       sync.Mutex
+	profiles []Profile
+	updates  []string
+}
+
+func (c *profileCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q := r.URL.Query()
+	var v interface{}
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/v2/profiles.json":
+		if q.Get("expand") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// the server caps per_page at 1
+		v = []Profile{}
+		if page, _ := strconv.Atoi(q.Get("page")); page >= 1 && page <= len(c.profiles) {
+			v = c.profiles[page-1 : page]
+		}
+	case r.Method == "POST" && r.URL.Path == "/v2/profiles.json":
+		p := Profile{ID: "id" + strconv.Itoa(len(c.profiles)+1), Name: q.Get("name")}
+		p.Width, _ = strconv.Atoi(q.Get("width"))
+		p.Upscale = q.Get("upscale") == "true"
+		c.profiles = append(c.profiles, p)
+		v = p
+	case r.Method == "PUT":
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/profiles/"), ".json")
+		for _, k := range []string{"access_key", "cloud_id", "signature", "timestamp"} {
+			q.Del(k)
+		}
+		c.updates = append(c.updates, id+" "+q.Encode())
+		v = Profile{ID: id, Name: q.Get("name")}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	mustWrite(w, b)
+}
+
+func TestExportImportProfiles(t *testing.T) {
+	src := &profileCloud{profiles: []Profile{
+		{ID: "a", Name: "webm", Width: 640, Upscale: true, Extra: Extra{"tiles": json.RawMessage(`4`)}},
+		{ID: "b", Name: "h264", Width: 1280, Encryption: true, EncryptionKey: "secret"},
+	}}
+	ts := httptest.NewServer(src)
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+
+	var buf bytes.Buffer
+	if err := m.ExportProfiles(&buf, FormatJSON); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	var yml bytes.Buffer
+	if err := m.ExportProfiles(&yml, FormatYAML); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	for _, f := range []Format{FormatJSON, FormatYAML} {
+		in := buf.String()
+		if f == FormatYAML {
+			in = yml.String()
+		}
+		doc, err := readProfiles(strings.NewReader(in), f)
+		if err != nil {
+			t.Fatalf("want err=nil; got %v (f=%s)", err, f)
+		}
+		if len(doc) != 2 || doc[0]["name"] != "h264" || doc[1]["name"] != "webm" {
+			t.Fatalf("want profiles sorted by name; got %v (f=%s)", doc, f)
+		}
+		// all fields but Extra and the encryption key
+		if len(doc[0]) != reflect.TypeOf(NewProfileRequest{}).NumField()-2 {
+			t.Errorf("want every settable field but the encryption key; got %v (f=%s)", doc[0], f)
+		}
+		if doc[0]["upscale"] != false || doc[1]["upscale"] != true || doc[0]["encryption"] != true {
+			t.Errorf("want zero values exported; got %v (f=%s)", doc, f)
+		}
+		if _, ok := doc[0]["encryption_key"]; ok || strings.Contains(in, "secret") {
+			t.Errorf("want encryption key redacted; got %s (f=%s)", in, f)
+		}
+		if _, ok := doc[1]["tiles"]; ok {
+			t.Errorf("want read-only fields left out; got %v (f=%s)", doc[1], f)
+		}
+	}
+
+	cases := []struct {
+		s       ConflictStrategy
+		actions []string
+		names   []string
+		updates int
+	}{
+		{ConflictSkip, []string{"skipped", "created"}, []string{"h264", "webm"}, 0},
+		{ConflictOverwrite, []string{"overwritten", "created"}, []string{"h264", "webm"}, 1},
+		{ConflictRename, []string{"renamed", "created"}, []string{"h264", "h264_2", "webm"}, 0},
+	}
+	for i, c := range cases {
+		for _, f := range []Format{FormatJSON, FormatYAML} {
+			dst := &profileCloud{profiles: []Profile{{ID: "x", Name: "h264", Width: 320}}}
+			ts := httptest.NewServer(dst)
+			in := buf.String()
+			if f == FormatYAML {
+				in = yml.String()
+			}
+			rep, err := newManager(ts.URL, t).ImportProfiles(strings.NewReader(in), f, c.s)
+			ts.Close()
+			if err != nil {
+				t.Fatalf("want err=nil; got %v (i=%d, f=%s)", err, i, f)
+			}
+			var actions, names []string
+			for _, res := range rep.Results {
+				actions = append(actions, res.Action)
+				if res.Err != nil {
+					t.Errorf("want err=nil; got %v (i=%d, f=%s)", res.Err, i, f)
+				}
+			}
+			for _, p := range dst.profiles {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(actions, c.actions) {
+				t.Errorf("want actions=%v; got %v (i=%d, f=%s)", c.actions, actions, i, f)
+			}
+			if !reflect.DeepEqual(names, c.names) {
+				t.Errorf("want profiles=%v; got %v (i=%d, f=%s)", c.names, names, i, f)
+			}
+			if !reflect.DeepEqual(rep.Conflicts(), []string{"h264"}) {
+				t.Errorf("want conflicts=[h264]; got %v (i=%d, f=%s)", rep.Conflicts(), i, f)
+			}
+			if len(dst.updates) != c.updates {
+				t.Errorf("want %d updates; got %v (i=%d, f=%s)", c.updates, dst.updates, i, f)
+			}
+			if c.updates > 0 {
+				q, err := url.ParseQuery(strings.TrimPrefix(dst.updates[0], "x "))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if q.Get("upscale") != "false" || q.Get("width") != "1280" || q.Get("encryption") != "true" ||
+					q.Get("h264_crf") != "0" || q["encryption_key"] != nil {
+					t.Errorf("want overwrite to send every exported field; got %s", dst.updates[0])
+				}
+			}
+		}
+	}
+}
+
+func TestImportProfilesInvalid(t *testing.T) {
+	m := newManager("http://localhost", t)
+	for i, in := range []string{`{"profiles":[{"width":640}]}`, `{`} {
+		if _, err := m.ImportProfiles(strings.NewReader(in), FormatJSON, ConflictSkip); err == nil {
+			t.Errorf("want err!=nil (i=%d)", i)
+		}
+	}
+	if _, err := m.ImportProfiles(strings.NewReader(""), Format("xml"), ConflictSkip); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
+
+func TestImportProfilesCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cloud := &profileCloud{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			defer cancel()
+		}
+		cloud.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t).WithContext(ctx)
+	in := `{"profiles":[{"name":"a"},{"name":"b"},{"name":"c"}]}`
+	rep, err := m.ImportProfiles(strings.NewReader(in), FormatJSON, ConflictSkip)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(cloud.profiles) != 1 {
+		t.Errorf("want 1 profile created before the cancellation; got %d", len(cloud.profiles))
+	}
+	if res := rep.Results[2]; res.Err != context.Canceled {
+		t.Errorf("want err=%v; got %v", context.Canceled, res.Err)
+	}
+}
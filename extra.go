@@ -0,0 +1,111 @@
+package panda
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Extra holds the fields of a Panda response which the model does not know.
+// They are kept when the model is marshalled again, so that attributes added
+// to Panda can be read before the library catches up and are not lost on update.
+type Extra map[string]json.RawMessage
+
+// Get decodes the field with the given name into v and reports whether it is present
+func (x Extra) Get(name string, v interface{}) (bool, error) {
+	raw, ok := x[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Values returns the string, number and boolean fields as request parameters
+func (x Extra) Values() url.Values {
+	v := make(url.Values)
+	for k, raw := range x {
+		var s interface{}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			continue
+		}
+		switch s := s.(type) {
+		case string:
+			v.Set(k, s)
+		case float64, bool:
+			v.Set(k, string(raw))
+		}
+	}
+	return v
+}
+
+// addExtra sets the parameters for the extra fields which are not set already
+func addExtra(params url.Values, x Extra) {
+	for k, v := range x.Values() {
+		if _, ok := params[k]; !ok {
+			params[k] = v
+		}
+	}
+}
+
+var knownFields sync.Map // map[reflect.Type]map[string]bool
+
+// fieldNames returns the JSON names of the fields of struct type t
+func fieldNames(t reflect.Type) map[string]bool {
+	if m, ok := knownFields.Load(t); ok {
+		return m.(map[string]bool)
+	}
+	m := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			m[name] = true
+		}
+	}
+	knownFields.Store(t, m)
+	return m
+}
+
+// unmarshalExtra decodes b into the model v and the fields v does not know into x.
+// v must be a pointer to a struct type without a custom UnmarshalJSON.
+func unmarshalExtra(b []byte, v interface{}, x *Extra) error {
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	known := fieldNames(reflect.TypeOf(v).Elem())
+	*x = nil
+	for k, raw := range all {
+		if known[k] {
+			continue
+		}
+		if *x == nil {
+			*x = make(Extra)
+		}
+		(*x)[k] = raw
+	}
+	return nil
+}
+
+// marshalExtra encodes the model v together with the extra fields. Known fields
+// take precedence over extra fields of the same name.
+func marshalExtra(v interface{}, x Extra) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(x) == 0 {
+		return b, err
+	}
+	var all map[string]json.RawMessage
+	if err = json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	for k, raw := range x {
+		if _, ok := all[k]; !ok {
+			all[k] = raw
+		}
+	}
+	return json.Marshal(all)
+}
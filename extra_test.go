@@ -0,0 +1,82 @@
+package panda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtraRoundTrip(t *testing.T) {
+	b := []byte(`{"id":"v1","status":"success","hdr":true,"tags":["a","b"],"events":{"video_created":true,"video_deleted":true}}`)
+	v := new(Video)
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if v.ID != "v1" || v.Status != StatusSuccess {
+		t.Errorf("want known fields to be decoded; got %+v", v)
+	}
+	var tags []string
+	if ok, err := v.Extra.Get("tags", &tags); !ok || err != nil || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("want tags=[a b]; got %v (ok=%t, err=%v)", tags, ok, err)
+	}
+	if ok, _ := v.Extra.Get("id", new(string)); ok {
+		t.Error("want known fields not to be kept in Extra")
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	var got, exp map[string]interface{}
+	if err = json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(b, &exp); err != nil {
+		t.Fatal(err)
+	}
+	for k := range exp {
+		if !reflect.DeepEqual(got[k], exp[k]) {
+			t.Errorf("want %s=%v; got %v", k, exp[k], got[k])
+		}
+	}
+
+	n := new(Notification)
+	if err := json.Unmarshal(b, n); err != nil {
+		t.Fatal(err)
+	}
+	if !n.Events.VideoCreated || n.Events.Extra == nil {
+		t.Errorf("want nested model to keep extra fields; got %+v", n.Events)
+	}
+	if err := json.Unmarshal([]byte(`{"id":"v2"}`), v); err != nil || v.Extra != nil {
+		t.Errorf("want Extra to be reset; got %v (err=%v)", v.Extra, err)
+	}
+}
+
+func TestUpdateSendsExtra(t *testing.T) {
+	var q url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q = r.URL.Query()
+		mustWrite(w, []byte(`{"id":"p1","name":"h264","hdr_mode":"pq"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	p := &Profile{ID: "p1", Name: "h264", Extra: Extra{
+		"hdr_mode": json.RawMessage(`"pq"`),
+		"tiles":    json.RawMessage(`4`),
+		"name":     json.RawMessage(`"ignored"`),
+		"layout":   json.RawMessage(`{"x":1}`),
+	}}
+	if err := m.Update(p); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	for k, exp := range map[string]string{"hdr_mode": "pq", "tiles": "4", "name": "h264", "layout": ""} {
+		if got := q.Get(k); got != exp {
+			t.Errorf("want %s=%q; got %q", k, exp, got)
+		}
+	}
+	if ok, _ := p.Extra.Get("hdr_mode", new(string)); !ok {
+		t.Error("want updated profile to keep extra fields")
+	}
+}
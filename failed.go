@@ -0,0 +1,78 @@
+package panda
+
+import "sort"
+
+// FailureGroup collects failed encodings which failed in the same way for the
+// same profile
+type FailureGroup struct {
+	ErrorClass   string
+	ErrorMessage string
+	ProfileName  string
+	Encodings    []Encoding
+}
+
+type failureKey struct {
+	class, message, profile string
+}
+
+// FailedEncodings gets all encodings with StatusFail and groups them by error class,
+// error message and profile name. Groups are ordered by size, largest first.
+func (m *Manager) FailedEncodings() ([]FailureGroup, error) {
+	es, err := m.AllEncodings(&EncodingRequest{Status: StatusFail})
+	if err != nil {
+		return nil, err
+	}
+	return groupFailures(es), nil
+}
+
+func groupFailures(es []Encoding) []FailureGroup {
+	idx := map[failureKey]int{}
+	var gs []FailureGroup
+	for _, e := range es {
+		k := failureKey{e.ErrorClass, e.ErrorMessage, e.ProfileName}
+		i, ok := idx[k]
+		if !ok {
+			i = len(gs)
+			idx[k] = i
+			gs = append(gs, FailureGroup{
+				ErrorClass:   e.ErrorClass,
+				ErrorMessage: e.ErrorMessage,
+				ProfileName:  e.ProfileName,
+			})
+		}
+		gs[i].Encodings = append(gs[i].Encodings, e)
+	}
+	sort.SliceStable(gs, func(i, j int) bool { return len(gs[i].Encodings) > len(gs[j].Encodings) })
+	return gs
+}
+
+// RetryFailed retries all failed encodings belonging to groups for which retry
+// returns true. A nil retry selects all groups. It returns the selected groups and
+// the report of the retries, in the same order.
+func (m *Manager) RetryFailed(retry func(*FailureGroup) bool,
+	opts *BatchOptions) ([]FailureGroup, *BatchReport, error) {
+	gs, err := m.FailedEncodings()
+	if err != nil {
+		return nil, nil, err
+	}
+	var selected []FailureGroup
+	var items []BatchResult
+	for i := range gs {
+		if retry != nil && !retry(&gs[i]) {
+			continue
+		}
+		selected = append(selected, gs[i])
+		for _, e := range gs[i].Encodings {
+			items = append(items, BatchResult{
+				Key:         "retry/" + e.ID,
+				VideoID:     e.VideoID,
+				ProfileName: e.ProfileName,
+				EncodingID:  e.ID,
+			})
+		}
+	}
+	report, err := runBatch(m.Client.context(), items, opts, func(res *BatchResult) error {
+		return m.Retry(res.EncodingID)
+	})
+	return selected, report, err
+}
@@ -0,0 +1,61 @@
+package panda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRetryFailed(t *testing.T) {
+	failed := []Encoding{
+		{ID: "e1", ProfileName: "h264", ErrorClass: "Timeout", ErrorMessage: "timed out", Status: StatusFail},
+		{ID: "e2", ProfileName: "webm", ErrorClass: "BadInput", ErrorMessage: "corrupt", Status: StatusFail},
+		{ID: "e3", ProfileName: "h264", ErrorClass: "Timeout", ErrorMessage: "timed out", Status: StatusFail},
+		{ID: "e4", ProfileName: "webm", ErrorClass: "Timeout", ErrorMessage: "timed out", Status: StatusFail},
+	}
+	var mu sync.Mutex
+	var retried []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/retry.json") {
+			retried = append(retried, strings.Split(r.URL.Path, "/")[3])
+			mustWrite(w, []byte(`{}`))
+			return
+		}
+		if r.URL.Query().Get("page") != "1" {
+			mustWrite(w, []byte(`[]`))
+			return
+		}
+		b, _ := json.Marshal(failed)
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+
+	gs, err := m.FailedEncodings()
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(gs) != 3 || len(gs[0].Encodings) != 2 || gs[0].ProfileName != "h264" {
+		t.Fatalf("want 3 groups with h264 timeouts first; got %#v", gs)
+	}
+
+	selected, report, err := m.RetryFailed(func(g *FailureGroup) bool {
+		return g.ErrorClass == "Timeout"
+	}, nil)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(selected) != 2 || len(report.Results) != 3 || report.Succeeded() != 3 {
+		t.Errorf("want 2 groups with 3 encodings retried; got %d groups, %d results", len(selected), len(report.Results))
+	}
+	sort.Strings(retried)
+	if strings.Join(retried, ",") != "e1,e3,e4" {
+		t.Errorf("want e1,e3,e4 to be retried; got %v", retried)
+	}
+}
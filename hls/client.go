@@ -0,0 +1,144 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatusError is returned when a playlist cannot be fetched
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("hls: %d fetching %s", e.Code, e.URL)
+}
+
+// defaultClient is used by clients without an HTTPClient, so that a hung server
+// does not block forever
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// Client fetches playlists over HTTP. URIs of variants, segments and keys in
+// the returned playlists are resolved against the playlist's URL.
+type Client struct {
+	// HTTPClient defaults to a client with a timeout of 30 seconds
+	HTTPClient *http.Client
+
+	ctx context.Context
+}
+
+// WithContext returns a client whose requests carry the given context
+func (cl *Client) WithContext(ctx context.Context) *Client {
+	c := *cl
+	c.ctx = ctx
+	return &c
+}
+
+func (cl *Client) context() context.Context {
+	if cl.ctx != nil {
+		return cl.ctx
+	}
+	return context.Background()
+}
+
+func (cl *Client) httpclient() *http.Client {
+	if cl.HTTPClient != nil {
+		return cl.HTTPClient
+	}
+	return defaultClient
+}
+
+func (cl *Client) fetch(u string, fn func(resp *http.Response) error) error {
+	req, err := http.NewRequestWithContext(cl.context(), "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := cl.httpclient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{URL: u, Code: resp.StatusCode}
+	}
+	return fn(resp)
+}
+
+// Master fetches and parses the master playlist under the given URL
+func (cl *Client) Master(u string) (p *MasterPlaylist, err error) {
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	err = cl.fetch(u, func(resp *http.Response) (err error) {
+		p, err = DecodeMaster(resp.Body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.Variants {
+		if p.Variants[i].URI, err = resolve(base, p.Variants[i].URI); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Media fetches and parses the media playlist under the given URL
+func (cl *Client) Media(u string) (p *MediaPlaylist, err error) {
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	err = cl.fetch(u, func(resp *http.Response) (err error) {
+		p, err = DecodeMedia(resp.Body)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := map[*Key]bool{}
+	for i := range p.Segments {
+		s := &p.Segments[i]
+		if s.URI, err = resolve(base, s.URI); err != nil {
+			return nil, err
+		}
+		if s.Key != nil && !keys[s.Key] && s.Key.URI != "" {
+			keys[s.Key] = true
+			if s.Key.URI, err = resolve(base, s.Key.URI); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// Variants fetches the master playlist under the given URL together with the media
+// playlists of all its variants. The media playlists are returned in the order of
+// the master playlist's variants.
+func (cl *Client) Variants(u string) (*MasterPlaylist, []*MediaPlaylist, error) {
+	m, err := cl.Master(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	ps := make([]*MediaPlaylist, len(m.Variants))
+	for i, v := range m.Variants {
+		if ps[i], err = cl.Media(v.URI); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, ps, nil
+}
+
+func resolve(base *url.URL, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(u).String(), nil
+}
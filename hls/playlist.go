@@ -0,0 +1,324 @@
+// Package hls fetches and inspects HLS playlists produced by Panda, both for
+// VOD encodings and live streams
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotPlaylist is returned when the parsed content does not start with #EXTM3U
+var ErrNotPlaylist = errors.New("hls: not an M3U8 playlist")
+
+// Resolution holds the pixel dimensions of a variant stream
+type Resolution struct {
+	Width  int
+	Height int
+}
+
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// Variant is a single stream listed in a master playlist
+type Variant struct {
+	URI              string
+	Bandwidth        int
+	AverageBandwidth int
+	Resolution       Resolution
+	Codecs           []string
+	FrameRate        float64
+}
+
+// MasterPlaylist lists the variant streams of an adaptive bitrate output
+type MasterPlaylist struct {
+	Version  int
+	Variants []Variant
+}
+
+// Key describes how the segments following an #EXT-X-KEY tag are encrypted
+type Key struct {
+	Method string
+	URI    string
+	IV     string
+}
+
+// Segment is a single media segment of a media playlist
+type Segment struct {
+	URI      string
+	Duration time.Duration
+	Title    string
+	// Sequence is the media sequence number of the segment
+	Sequence int
+	// Discontinuity is true if the segment is preceded by #EXT-X-DISCONTINUITY
+	Discontinuity bool
+	// Key is nil for unencrypted segments
+	Key *Key
+}
+
+// MediaPlaylist lists the media segments of a single variant stream
+type MediaPlaylist struct {
+	Version        int
+	TargetDuration time.Duration
+	MediaSequence  int
+	PlaylistType   string
+	// Ended is true if the playlist contains #EXT-X-ENDLIST, which is the case for
+	// VOD outputs and live streams which have finished
+	Ended    bool
+	Segments []Segment
+}
+
+// Duration returns the sum of all segment durations
+func (p *MediaPlaylist) Duration() (d time.Duration) {
+	for _, s := range p.Segments {
+		d += s.Duration
+	}
+	return
+}
+
+// Discontinuities returns the number of discontinuities in the playlist
+func (p *MediaPlaylist) Discontinuities() (n int) {
+	for _, s := range p.Segments {
+		if s.Discontinuity {
+			n++
+		}
+	}
+	return
+}
+
+// Encrypted reports whether any of the segments is encrypted
+func (p *MediaPlaylist) Encrypted() bool {
+	for _, s := range p.Segments {
+		if s.Key != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// LastSequence returns the media sequence number of the last segment or -1 if
+// the playlist has no segments
+func (p *MediaPlaylist) LastSequence() int {
+	if len(p.Segments) == 0 {
+		return -1
+	}
+	return p.Segments[len(p.Segments)-1].Sequence
+}
+
+// Decode parses either a master or a media playlist. The returned value is
+// a *MasterPlaylist or a *MediaPlaylist.
+func Decode(r io.Reader) (interface{}, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if strings.HasPrefix(l.text, "#EXT-X-STREAM-INF") {
+			return parseMaster(lines)
+		}
+	}
+	return parseMedia(lines)
+}
+
+// DecodeMaster parses a master playlist
+func DecodeMaster(r io.Reader) (*MasterPlaylist, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseMaster(lines)
+}
+
+// DecodeMedia parses a media playlist
+func DecodeMedia(r io.Reader) (*MediaPlaylist, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseMedia(lines)
+}
+
+// line is a non-blank line of a playlist with its 1-based number
+type line struct {
+	n    int
+	text string
+}
+
+// readLines returns the non-blank lines following #EXTM3U, numbered as in r
+func readLines(r io.Reader) (lines []line, err error) {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		if l := strings.TrimSpace(sc.Text()); l != "" {
+			lines = append(lines, line{n, l})
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0].text != "#EXTM3U" {
+		return nil, ErrNotPlaylist
+	}
+	return lines[1:], nil
+}
+
+func splitTag(l string) (tag, value string) {
+	if i := strings.IndexByte(l, ':'); i >= 0 {
+		return l[:i], l[i+1:]
+	}
+	return l, ""
+}
+
+func parseMaster(lines []line) (*MasterPlaylist, error) {
+	p := &MasterPlaylist{}
+	var cur *Variant
+	for _, ln := range lines {
+		l := ln.text
+		if !strings.HasPrefix(l, "#") {
+			if cur == nil {
+				return nil, fmt.Errorf("hls: line %d: URI without #EXT-X-STREAM-INF", ln.n)
+			}
+			cur.URI = l
+			p.Variants = append(p.Variants, *cur)
+			cur = nil
+			continue
+		}
+		tag, value := splitTag(l)
+		switch tag {
+		case "#EXT-X-VERSION":
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("hls: line %d: %v", ln.n, err)
+			}
+			p.Version = v
+		case "#EXT-X-STREAM-INF":
+			v, err := parseVariant(value)
+			if err != nil {
+				return nil, fmt.Errorf("hls: line %d: %v", ln.n, err)
+			}
+			cur = v
+		}
+	}
+	return p, nil
+}
+
+func parseVariant(value string) (*Variant, error) {
+	v := &Variant{}
+	for k, a := range parseAttributes(value) {
+		var err error
+		switch k {
+		case "BANDWIDTH":
+			v.Bandwidth, err = strconv.Atoi(a)
+		case "AVERAGE-BANDWIDTH":
+			v.AverageBandwidth, err = strconv.Atoi(a)
+		case "RESOLUTION":
+			_, err = fmt.Sscanf(a, "%dx%d", &v.Resolution.Width, &v.Resolution.Height)
+		case "CODECS":
+			for _, c := range strings.Split(a, ",") {
+				v.Codecs = append(v.Codecs, strings.TrimSpace(c))
+			}
+		case "FRAME-RATE":
+			v.FrameRate, err = strconv.ParseFloat(a, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", k, a, err)
+		}
+	}
+	return v, nil
+}
+
+func parseMedia(lines []line) (*MediaPlaylist, error) {
+	p := &MediaPlaylist{}
+	var (
+		cur   Segment
+		key   *Key
+		inSeg bool
+	)
+	for _, ln := range lines {
+		l := ln.text
+		if !strings.HasPrefix(l, "#") {
+			if !inSeg {
+				return nil, fmt.Errorf("hls: line %d: URI without #EXTINF", ln.n)
+			}
+			cur.URI = l
+			cur.Key = key
+			cur.Sequence = p.MediaSequence + len(p.Segments)
+			p.Segments = append(p.Segments, cur)
+			cur, inSeg = Segment{}, false
+			continue
+		}
+		tag, value := splitTag(l)
+		var err error
+		switch tag {
+		case "#EXT-X-VERSION":
+			p.Version, err = strconv.Atoi(value)
+		case "#EXT-X-TARGETDURATION":
+			var n int
+			n, err = strconv.Atoi(value)
+			p.TargetDuration = time.Duration(n) * time.Second
+		case "#EXT-X-MEDIA-SEQUENCE":
+			p.MediaSequence, err = strconv.Atoi(value)
+		case "#EXT-X-PLAYLIST-TYPE":
+			p.PlaylistType = value
+		case "#EXT-X-ENDLIST":
+			p.Ended = true
+		case "#EXT-X-DISCONTINUITY":
+			cur.Discontinuity = true
+		case "#EXT-X-KEY":
+			attrs := parseAttributes(value)
+			if attrs["METHOD"] == "NONE" {
+				key = nil
+				break
+			}
+			key = &Key{Method: attrs["METHOD"], URI: attrs["URI"], IV: attrs["IV"]}
+		case "#EXTINF":
+			dur, title := value, ""
+			if j := strings.IndexByte(value, ','); j >= 0 {
+				dur, title = value[:j], value[j+1:]
+			}
+			var secs float64
+			secs, err = strconv.ParseFloat(dur, 64)
+			cur.Duration = time.Duration(secs * float64(time.Second))
+			cur.Title = title
+			inSeg = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hls: line %d: %v", ln.n, err)
+		}
+	}
+	return p, nil
+}
+
+// parseAttributes parses an attribute list such as BANDWIDTH=1000,CODECS="a,b".
+// Quotes are removed from quoted values.
+func parseAttributes(s string) map[string]string {
+	attrs := map[string]string{}
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		k := strings.TrimSpace(s[:eq])
+		s = s[eq+1:]
+		var v string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				v, s = s[1:], ""
+			} else {
+				v, s = s[1:end+1], s[end+2:]
+			}
+			s = strings.TrimPrefix(s, ",")
+		} else if c := strings.IndexByte(s, ','); c >= 0 {
+			v, s = s[:c], s[c+1:]
+		} else {
+			v, s = s, ""
+		}
+		attrs[k] = v
+	}
+	return attrs
+}
@@ -0,0 +1,187 @@
+package hls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const masterPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AVERAGE-BANDWIDTH=1000000,RESOLUTION=640x360,CODECS="avc1.42e00a,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2",FRAME-RATE=29.970
+high/index.m3u8
+`
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXTINF:9.009,
+seg7.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x1
+#EXTINF:9.009,
+seg8.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:3.003,
+seg9.ts
+#EXT-X-ENDLIST
+`
+
+func TestDecodeMaster(t *testing.T) {
+	p, err := DecodeMaster(strings.NewReader(masterPlaylist))
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := &MasterPlaylist{
+		Version: 3,
+		Variants: []Variant{
+			{
+				URI:              "low/index.m3u8",
+				Bandwidth:        1280000,
+				AverageBandwidth: 1000000,
+				Resolution:       Resolution{640, 360},
+				Codecs:           []string{"avc1.42e00a", "mp4a.40.2"},
+			},
+			{
+				URI:        "high/index.m3u8",
+				Bandwidth:  2560000,
+				Resolution: Resolution{1280, 720},
+				Codecs:     []string{"avc1.4d401f", "mp4a.40.2"},
+				FrameRate:  29.97,
+			},
+		},
+	}
+	if !reflect.DeepEqual(p, exp) {
+		t.Errorf("want %#v; got %#v", exp, p)
+	}
+}
+
+func TestDecodeMedia(t *testing.T) {
+	p, err := DecodeMedia(strings.NewReader(mediaPlaylist))
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if p.TargetDuration != 10*time.Second || p.MediaSequence != 7 || !p.Ended || p.PlaylistType != "VOD" {
+		t.Errorf("unexpected playlist header %#v", p)
+	}
+	if len(p.Segments) != 3 {
+		t.Fatalf("want 3 segments; got %d", len(p.Segments))
+	}
+	if d := p.Duration(); d != 21021*time.Millisecond {
+		t.Errorf("want duration=21.021s; got %v", d)
+	}
+	if n := p.Discontinuities(); n != 1 || !p.Segments[2].Discontinuity {
+		t.Errorf("want discontinuity before the last segment; got %d", n)
+	}
+	if p.Segments[0].Key != nil || p.Segments[2].Key != nil {
+		t.Error("want first and last segment to be unencrypted")
+	}
+	exp := &Key{Method: "AES-128", URI: "key.bin", IV: "0x1"}
+	if !reflect.DeepEqual(p.Segments[1].Key, exp) {
+		t.Errorf("want key=%#v; got %#v", exp, p.Segments[1].Key)
+	}
+	if seq := p.LastSequence(); seq != 9 {
+		t.Errorf("want last sequence=9; got %d", seq)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		in  string
+		typ interface{}
+		err error
+	}{
+		{masterPlaylist, &MasterPlaylist{}, nil},
+		{mediaPlaylist, &MediaPlaylist{}, nil},
+		{"not a playlist", nil, ErrNotPlaylist},
+	}
+	for i, cas := range cases {
+		p, err := Decode(strings.NewReader(cas.in))
+		if err != cas.err {
+			t.Errorf("want err=%v; got %v (i=%d)", cas.err, err, i)
+		}
+		if reflect.TypeOf(p) != reflect.TypeOf(cas.typ) {
+			t.Errorf("want %T; got %T (i=%d)", cas.typ, p, i)
+		}
+	}
+}
+
+func TestDecodeErrorLine(t *testing.T) {
+	cases := []struct {
+		playlist string
+		line     string
+	}{
+		{"#EXTM3U\n\n#EXT-X-VERSION:3\n\nseg.ts\n", "line 5:"},
+		{"\n#EXTM3U\n#EXT-X-TARGETDURATION:x\n", "line 3:"},
+		{"#EXTM3U\n\n\n#EXT-X-STREAM-INF:BANDWIDTH=x\nlow/index.m3u8\n", "line 4:"},
+	}
+	for i, c := range cases {
+		_, err := Decode(strings.NewReader(c.playlist))
+		if err == nil || !strings.Contains(err.Error(), c.line) {
+			t.Errorf("want error at %s got %v (i=%d)", c.line, err, i)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/out/master.m3u8":
+			w.Write([]byte(masterPlaylist))
+		case "/out/low/index.m3u8", "/out/high/index.m3u8":
+			w.Write([]byte(mediaPlaylist))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	cl := &Client{}
+	m, ps, err := cl.Variants(ts.URL + "/out/master.m3u8")
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if exp := ts.URL + "/out/low/index.m3u8"; m.Variants[0].URI != exp {
+		t.Errorf("want uri=%s; got %s", exp, m.Variants[0].URI)
+	}
+	if len(ps) != 2 {
+		t.Fatalf("want 2 media playlists; got %d", len(ps))
+	}
+	if exp := ts.URL + "/out/high/seg7.ts"; ps[1].Segments[0].URI != exp {
+		t.Errorf("want uri=%s; got %s", exp, ps[1].Segments[0].URI)
+	}
+	if exp := ts.URL + "/out/low/key.bin"; ps[0].Segments[1].Key.URI != exp {
+		t.Errorf("want key uri=%s; got %s", exp, ps[0].Segments[1].Key.URI)
+	}
+	_, err = cl.Media(ts.URL + "/missing.m3u8")
+	if e, ok := err.(*StatusError); !ok || e.Code != http.StatusNotFound {
+		t.Errorf("want 404 StatusError; got %v", err)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := (&Client{}).WithContext(ctx).Media(ts.URL + "/hung.m3u8")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want err=%v; got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("want the request to be cancelled; took %v", d)
+	}
+}
@@ -0,0 +1,218 @@
+// Command genmodels generates the model types of the panda package from the JSON
+// fixtures in the json directory.
+//
+// Every fixture file becomes a struct named after the file, and every nested
+// object a struct named after its key. JSON numbers are float64 unless the config
+// file overrides the type of the key. Fields get json and url tags, the url tags
+// can be given extra options in the config file. Types listed as extra keep unknown
+// fields in an Extra field and get JSON methods round-tripping them:
+//
+//	genmodels -dir=json -config=internal/cmd/genmodels/config.json -o=models.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// config is read from the file given with -config
+type config struct {
+	// Package is the name of the generated package
+	Package string `json:"package"`
+	// Initialisms are written in upper case in field and type names
+	Initialisms []string `json:"initialisms"`
+	// Types maps JSON keys to the Go type of their fields
+	Types map[string]string `json:"types"`
+	// URLOptions maps JSON keys to additional options of their url tags
+	URLOptions map[string]string `json:"url_options"`
+	// Extra lists the types which keep unknown JSON fields in an Extra field
+	Extra []string `json:"extra"`
+}
+
+func readConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(config)
+	if err = json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+type field struct {
+	name string
+	typ  string
+	key  string
+}
+
+type generator struct {
+	cfg   *config
+	types map[string][]field
+}
+
+// generate returns the formatted source of the models for the fixtures in dir
+func generate(cfg *config, dir string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{cfg: cfg, types: make(map[string][]field)}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var obj map[string]interface{}
+		if err = json.Unmarshal(b, &obj); err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".json")
+		if err = g.add(g.goName(name), obj); err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+	}
+	return g.source()
+}
+
+func (g *generator) add(name string, obj map[string]interface{}) error {
+	if _, ok := g.types[name]; ok {
+		return fmt.Errorf("type %s is defined twice", name)
+	}
+	g.types[name] = nil
+	fs := make([]field, 0, len(obj))
+	for k, v := range obj {
+		typ, err := g.typeOf(k, v)
+		if err != nil {
+			return err
+		}
+		fs = append(fs, field{name: g.goName(k), typ: typ, key: k})
+	}
+	sort.Slice(fs, func(i, j int) bool { return fs[i].name < fs[j].name })
+	g.types[name] = fs
+	return nil
+}
+
+func (g *generator) typeOf(k string, v interface{}) (string, error) {
+	if t, ok := g.cfg.Types[k]; ok {
+		return t, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return "string", nil
+	case bool:
+		return "bool", nil
+	case float64:
+		return "float64", nil
+	case map[string]interface{}:
+		name := g.goName(k)
+		return name, g.add(name, v)
+	case []interface{}:
+		if len(v) == 0 {
+			return "", fmt.Errorf("cannot infer element type of empty array %q", k)
+		}
+		t, err := g.typeOf(k, v[0])
+		return "[]" + t, err
+	}
+	return "interface{}", nil
+}
+
+// goName converts a snake case JSON key to an exported Go identifier
+func (g *generator) goName(k string) string {
+	var b strings.Builder
+	for _, p := range strings.Split(k, "_") {
+		if p == "" {
+			continue
+		}
+		up := strings.ToUpper(p)
+		if g.initialism(up) {
+			b.WriteString(up)
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	return b.String()
+}
+
+func (g *generator) initialism(s string) bool {
+	return contains(g.cfg.Initialisms, s)
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+const extraMethods = `
+// UnmarshalJSON was autogenerated by go generate.
+func (v *%[1]s) UnmarshalJSON(b []byte) error {
+	type model %[1]s
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v %[1]s) MarshalJSON() ([]byte, error) {
+	type model %[1]s
+	return marshalExtra(model(v), v.Extra)
+}
+`
+
+func (g *generator) source() ([]byte, error) {
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Created by go generate; DO NOT EDIT\n\npackage %s\n", g.cfg.Package)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\n// %s was autogenerated by go generate.\ntype %s struct {\n", name, name)
+		for _, f := range g.types[name] {
+			opts := "omitempty"
+			if o, ok := g.cfg.URLOptions[f.key]; ok {
+				opts += "," + o
+			}
+			fmt.Fprintf(&buf, "%s %s `json:\"%s,omitempty\" url:\"%s,%s\"`\n", f.name, f.typ, f.key, f.key, opts)
+		}
+		if !contains(g.cfg.Extra, name) {
+			buf.WriteString("}\n")
+			continue
+		}
+		fmt.Fprintf(&buf, "Extra Extra `json:\"-\" url:\"-\"`\n}\n")
+		fmt.Fprintf(&buf, extraMethods, name)
+	}
+	return format.Source(buf.Bytes())
+}
+
+func main() {
+	dir := flag.String("dir", "json", "directory of the JSON fixtures")
+	cfgPath := flag.String("config", "internal/cmd/genmodels/config.json", "config file")
+	out := flag.String("o", "models.go", "output file")
+	flag.Parse()
+	cfg, err := readConfig(*cfgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := generate(cfg, *dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(*out, b, 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "genmodels: wrote %d types to %s\n", strings.Count(string(b), "\ntype "), *out)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestModelsUpToDate(t *testing.T) {
+	cfg, err := readConfig("config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generate(cfg, "../../../json")
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	cur, err := ioutil.ReadFile("../../../models.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, cur) {
+		t.Error("models.go is out of date, run go generate")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	g := &generator{cfg: &config{Initialisms: []string{"ID", "URL"}}}
+	cases := map[string]string{
+		"video_id":           "VideoID",
+		"encryption_key_url": "EncryptionKeyURL",
+		"encryption_iv":      "EncryptionIv",
+		"Keyframe_rate":      "KeyframeRate",
+		"h264_crf":           "H264Crf",
+		"new_video_request":  "NewVideoRequest",
+	}
+	for k, exp := range cases {
+		if got := g.goName(k); got != exp {
+			t.Errorf("want %s=%s; got %s", k, exp, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package panda
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rung is a rendition of an adaptive bitrate ladder. Width is derived from the
+// source's aspect ratio when the ladder is proposed.
+type Rung struct {
+	Height int
+	// VideoBitrate and AudioBitrate are in kbit/s
+	VideoBitrate int
+	AudioBitrate int
+	H264Profile  string
+	// H264Level is the lowest level to use, a higher one is chosen if the
+	// rendition's resolution, frame rate or bitrate require it
+	H264Level string
+}
+
+// DefaultLadder is a common H.264 ladder for 16:9 sources
+var DefaultLadder = []Rung{
+	{1080, 5000, 128, "high", ""},
+	{720, 3000, 128, "main", ""},
+	{480, 1200, 96, "main", ""},
+	{360, 800, 96, "baseline", ""},
+	{240, 400, 64, "baseline", ""},
+}
+
+// LadderOptions configures ProposeLadder and EncodeLadder
+type LadderOptions struct {
+	// Rungs to choose from, DefaultLadder if nil
+	Rungs []Rung
+	// SegmentDuration in seconds, keyframes are placed at every segment boundary.
+	// Defaults to 2 seconds.
+	SegmentDuration float64
+	// NamePrefix of the profile names, defaults to "abr"
+	NamePrefix string
+}
+
+// defaultFps is assumed for sources which do not report their frame rate
+const defaultFps = 30
+
+// ProposeLadder returns profile requests for the rungs of the ladder which fit the
+// source video. Rungs taller than the source are dropped, so the source is never
+// upscaled; if no rung fits, a single one with the source's resolution is
+// proposed. Bitrates are capped at the source's bitrate and all renditions use
+// the same frame rate and keyframe interval so that their segments align.
+func ProposeLadder(v *Video, opts *LadderOptions) ([]NewProfileRequest, error) {
+	if v.Width <= 0 || v.Height <= 0 {
+		return nil, errors.New("panda: video has no resolution")
+	}
+	if opts == nil {
+		opts = &LadderOptions{}
+	}
+	rungs := opts.Rungs
+	if rungs == nil {
+		rungs = DefaultLadder
+	}
+	seg := opts.SegmentDuration
+	if seg <= 0 {
+		seg = 2
+	}
+	prefix := opts.NamePrefix
+	if prefix == "" {
+		prefix = "abr"
+	}
+	fps := v.Fps
+	if fps <= 0 {
+		fps = defaultFps
+	}
+	gop := int(math.Round(fps * seg))
+	// profile names hold all settings derived from the source, so that renditions
+	// of sources with another frame rate or aspect ratio do not collide
+	fpsName := strings.Replace(strconv.FormatFloat(fps, 'f', -1, 64), ".", "_", 1)
+	var fit []Rung
+	for _, r := range rungs {
+		if r.Height <= v.Height {
+			fit = append(fit, r)
+		}
+	}
+	if len(fit) == 0 && len(rungs) > 0 {
+		r := rungs[len(rungs)-1]
+		r.Height = v.Height
+		fit = append(fit, r)
+	}
+	prs := make([]NewProfileRequest, 0, len(fit))
+	for _, r := range fit {
+		bitrate := r.VideoBitrate
+		if v.VideoBitrate > 0 && bitrate > v.VideoBitrate {
+			bitrate = v.VideoBitrate
+		}
+		height := even(float64(r.Height))
+		width := even(float64(height) * float64(v.Width) / float64(v.Height))
+		prs = append(prs, NewProfileRequest{
+			Name:             fmt.Sprintf("%s_%dx%d_%sfps_%dk_g%d", prefix, width, height, fpsName, bitrate, gop),
+			Title:            fmt.Sprintf("ABR %dx%d %vfps %dk", width, height, fps, bitrate),
+			PresetName:       "h264",
+			Extname:          ".mp4",
+			AspectMode:       ModeConstrain,
+			Width:            width,
+			Height:           height,
+			VideoBitrate:     bitrate,
+			AudioBitrate:     r.AudioBitrate,
+			Fps:              fps,
+			KeyframeInterval: gop,
+			H264Profile:      r.H264Profile,
+			H264Level:        h264Level(width, height, fps, bitrate, r.H264Profile, r.H264Level),
+		})
+	}
+	return prs, nil
+}
+
+// h264Levels are the limits of the H.264 levels from 3.0 up, as of Table A-1 of
+// the specification. Bitrates are in kbit/s for the baseline and main profiles.
+var h264Levels = []struct {
+	level string
+	// macroblocks per second and per frame
+	mbps, fs int
+	bitrate  int
+}{
+	{"3.0", 40500, 1620, 10000},
+	{"3.1", 108000, 3600, 14000},
+	{"3.2", 216000, 5120, 20000},
+	{"4.0", 245760, 8192, 20000},
+	{"4.1", 245760, 8192, 50000},
+	{"4.2", 522240, 8704, 50000},
+	{"5.0", 589824, 22080, 135000},
+	{"5.1", 983040, 36864, 240000},
+	{"5.2", 2073600, 36864, 240000},
+}
+
+// h264Level returns the lowest level, but at least atLeast, which allows encoding
+// the resolution at the frame rate and bitrate. The highest level is returned if
+// none does, or atLeast if it is higher than that.
+func h264Level(width, height int, fps float64, bitrate int, profile, atLeast string) string {
+	fs := ((width + 15) / 16) * ((height + 15) / 16)
+	mbps := int(math.Ceil(float64(fs) * fps))
+	if profile == "high" {
+		// the high profile allows 25% more bitrate
+		bitrate = bitrate * 4 / 5
+	}
+	lowest, _ := strconv.ParseFloat(atLeast, 64)
+	for _, l := range h264Levels {
+		n, _ := strconv.ParseFloat(l.level, 64)
+		if n >= lowest && fs <= l.fs && mbps <= l.mbps && bitrate <= l.bitrate {
+			return l.level
+		}
+	}
+	last := h264Levels[len(h264Levels)-1].level
+	if n, _ := strconv.ParseFloat(last, 64); lowest > n {
+		return atLeast
+	}
+	return last
+}
+
+// even rounds f down to an even integer, as required by H.264 with 4:2:0 chroma
+// subsampling. Rounding down never exceeds the source's resolution.
+func even(f float64) int {
+	return 2 * int(f/2)
+}
+
+// LadderRendition is a rung of a ladder encoded by EncodeLadder
+type LadderRendition struct {
+	Profile *Profile
+	// Reused is set if an existing profile matched the rung
+	Reused   bool
+	Encoding *Encoding
+}
+
+// matchesRung reports whether the profile encodes the same rendition as pr
+func matchesRung(p *Profile, pr *NewProfileRequest) bool {
+	return p.Width == pr.Width && p.Height == pr.Height &&
+		p.VideoBitrate == pr.VideoBitrate && p.AudioBitrate == pr.AudioBitrate &&
+		p.KeyframeInterval == pr.KeyframeInterval && p.Fps == pr.Fps &&
+		p.H264Profile == pr.H264Profile && p.H264Level == pr.H264Level &&
+		p.PresetName == pr.PresetName
+}
+
+// EncodeLadder proposes a ladder for the video with the given id and creates an
+// encoding for every rung. Existing profiles with the same settings are reused,
+// missing ones are created. An existing profile with the name of a rung but other
+// settings is an error. The renditions created before an error are returned
+// along with it.
+func (m *Manager) EncodeLadder(videoID string, opts *LadderOptions) ([]LadderRendition, error) {
+	v, err := m.Video(videoID)
+	if err != nil {
+		return nil, err
+	}
+	prs, err := ProposeLadder(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := m.AllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]LadderRendition, 0, len(prs))
+	for i := range prs {
+		var r LadderRendition
+		for j := range ps {
+			if matchesRung(&ps[j], &prs[i]) {
+				r.Profile, r.Reused = &ps[j], true
+				break
+			}
+		}
+		if r.Profile == nil {
+			for j := range ps {
+				if ps[j].Name == prs[i].Name {
+					return rs, fmt.Errorf("panda: profile %s exists with other settings", ps[j].Name)
+				}
+			}
+			if r.Profile, err = m.NewProfile(&prs[i]); err != nil {
+				return rs, err
+			}
+		}
+		r.Encoding, err = m.NewEncoding(&NewEncodingRequest{VideoID: v.ID, ProfileID: r.Profile.ID})
+		if err != nil {
+			return rs, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
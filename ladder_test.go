@@ -0,0 +1,194 @@
+package panda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestProposeLadder(t *testing.T) {
+	type rendition struct {
+		w, h, bitrate, keyframes int
+		level                    string
+	}
+	cases := []struct {
+		v   Video
+		exp []rendition
+	}{
+		{
+			Video{Width: 1280, Height: 720, VideoBitrate: 2000, Fps: 25},
+			[]rendition{{1280, 720, 2000, 50, "3.1"}, {852, 480, 1200, 50, "3.0"}, {640, 360, 800, 50, "3.0"},
+				{426, 240, 400, 50, "3.0"}},
+		},
+		{
+			Video{Width: 1920, Height: 1080},
+			[]rendition{{1920, 1080, 5000, 60, "4.0"}, {1280, 720, 3000, 60, "3.1"}, {852, 480, 1200, 60, "3.1"},
+				{640, 360, 800, 60, "3.0"}, {426, 240, 400, 60, "3.0"}},
+		},
+		{
+			Video{Width: 1920, Height: 1080, Fps: 60},
+			[]rendition{{1920, 1080, 5000, 120, "4.2"}, {1280, 720, 3000, 120, "3.2"}, {852, 480, 1200, 120, "3.1"},
+				{640, 360, 800, 120, "3.1"}, {426, 240, 400, 120, "3.0"}},
+		},
+		{
+			Video{Width: 320, Height: 180, VideoBitrate: 300, Fps: 24},
+			[]rendition{{320, 180, 300, 48, "3.0"}},
+		},
+		{
+			Video{Width: 320, Height: 181, VideoBitrate: 300, Fps: 30},
+			[]rendition{{318, 180, 300, 60, "3.0"}},
+		},
+		{
+			Video{Width: 427, Height: 240, Fps: 30},
+			[]rendition{{426, 240, 400, 60, "3.0"}},
+		},
+		{
+			Video{Width: 720, Height: 1280, Fps: 30},
+			[]rendition{{606, 1080, 5000, 60, "3.1"}, {404, 720, 3000, 60, "3.0"}, {270, 480, 1200, 60, "3.0"},
+				{202, 360, 800, 60, "3.0"}, {134, 240, 400, 60, "3.0"}},
+		},
+	}
+	for i, c := range cases {
+		prs, err := ProposeLadder(&c.v, nil)
+		if err != nil {
+			t.Fatalf("want err=nil; got %v (i=%d)", err, i)
+		}
+		var got []rendition
+		for _, pr := range prs {
+			got = append(got, rendition{pr.Width, pr.Height, pr.VideoBitrate, pr.KeyframeInterval, pr.H264Level})
+			if pr.Upscale || pr.AspectMode != ModeConstrain {
+				t.Errorf("want constrained renditions without upscaling; got %+v (i=%d)", pr, i)
+			}
+		}
+		if !reflect.DeepEqual(got, c.exp) {
+			t.Errorf("want %v; got %v (i=%d)", c.exp, got, i)
+		}
+	}
+	if _, err := ProposeLadder(&Video{}, nil); err == nil {
+		t.Error("want error for video without resolution")
+	}
+	prs, err := ProposeLadder(&Video{Width: 640, Height: 360}, &LadderOptions{
+		Rungs:           []Rung{{360, 700, 96, "main", "3.1"}},
+		SegmentDuration: 4,
+		NamePrefix:      "hls",
+	})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(prs) != 1 || prs[0].Name != "hls_640x360_30fps_700k_g120" || prs[0].KeyframeInterval != 120 || prs[0].H264Profile != "main" ||
+		prs[0].H264Level != "3.1" {
+		t.Errorf("want custom rung; got %+v", prs)
+	}
+}
+
+func TestEncodeLadder(t *testing.T) {
+	profiles := []Profile{{ID: "existing", Name: "sd", Width: 640, Height: 360, VideoBitrate: 800, AudioBitrate: 96,
+		Fps: 25, KeyframeInterval: 50, H264Profile: "baseline", H264Level: "3.0", PresetName: "h264"}}
+	var created, encoded []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		atoi := func(k string) int { n, _ := strconv.Atoi(q.Get(k)); return n }
+		var v interface{}
+		switch {
+		case r.URL.Path == "/v2/videos/v1.json":
+			v = Video{ID: "v1", Width: 854, Height: 480, Fps: 25}
+		case r.URL.Path == "/v2/videos/v2.json":
+			v = Video{ID: "v2", Width: 854, Height: 480, Fps: 30}
+		case r.Method == "GET" && r.URL.Path == "/v2/profiles.json":
+			v = profiles
+		case r.Method == "POST" && r.URL.Path == "/v2/profiles.json":
+			for _, p := range profiles {
+				if p.Name == q.Get("name") {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					mustWrite(w, []byte(`{"error":"DuplicateName"}`))
+					return
+				}
+			}
+			fps, _ := strconv.ParseFloat(q.Get("fps"), 64)
+			p := Profile{ID: q.Get("name"), Name: q.Get("name"), Width: atoi("width"), Height: atoi("height"),
+				VideoBitrate: atoi("video_bitrate"), AudioBitrate: atoi("audio_bitrate"), Fps: fps,
+				KeyframeInterval: atoi("keyframe_interval"), H264Profile: q.Get("h264_profile"),
+				H264Level: q.Get("h264_level"), PresetName: q.Get("preset_name")}
+			profiles = append(profiles, p)
+			created = append(created, p.Name)
+			v = p
+		case r.Method == "POST" && r.URL.Path == "/v2/encodings.json":
+			encoded = append(encoded, q.Get("profile_id"))
+			v = Encoding{ID: "e" + strconv.Itoa(len(encoded)), VideoID: q.Get("video_id"), ProfileID: q.Get("profile_id")}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(err)
+		}
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	cases := []struct {
+		video   string
+		created []string
+		encoded []string
+		reused  []bool
+	}{
+		{
+			"v1",
+			[]string{"abr_854x480_25fps_1200k_g50", "abr_426x240_25fps_400k_g50"},
+			[]string{"abr_854x480_25fps_1200k_g50", "existing", "abr_426x240_25fps_400k_g50"},
+			[]bool{false, true, false},
+		},
+		{
+			"v2",
+			[]string{"abr_854x480_30fps_1200k_g60", "abr_640x360_30fps_800k_g60", "abr_426x240_30fps_400k_g60"},
+			[]string{"abr_854x480_30fps_1200k_g60", "abr_640x360_30fps_800k_g60", "abr_426x240_30fps_400k_g60"},
+			[]bool{false, false, false},
+		},
+		{
+			"v1",
+			nil,
+			[]string{"abr_854x480_25fps_1200k_g50", "existing", "abr_426x240_25fps_400k_g50"},
+			[]bool{true, true, true},
+		},
+	}
+	for i, c := range cases {
+		created, encoded = nil, nil
+		rs, err := m.EncodeLadder(c.video, nil)
+		if err != nil {
+			t.Fatalf("want err=nil; got %v (i=%d)", err, i)
+		}
+		if !reflect.DeepEqual(created, c.created) {
+			t.Errorf("want created profiles=%v; got %v (i=%d)", c.created, created, i)
+		}
+		if !reflect.DeepEqual(encoded, c.encoded) {
+			t.Errorf("want encodings for profiles=%v; got %v (i=%d)", c.encoded, encoded, i)
+		}
+		var reused []bool
+		for _, r := range rs {
+			reused = append(reused, r.Reused)
+		}
+		if !reflect.DeepEqual(reused, c.reused) {
+			t.Errorf("want reused=%v; got %v (i=%d)", c.reused, reused, i)
+		}
+	}
+
+	profiles = append(profiles, Profile{ID: "taken", Name: "abr_854x480_24fps_1200k_g48"})
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/videos/v3.json":
+			mustWrite(w, []byte(`{"id":"v3","width":854,"height":480,"fps":24}`))
+		case "/v2/profiles.json":
+			b, _ := json.Marshal(profiles)
+			mustWrite(w, b)
+		default:
+			t.Errorf("want no request to %s %s", r.Method, r.URL.Path)
+		}
+	})
+	if _, err := m.EncodeLadder("v3", nil); err == nil {
+		t.Error("want error for a profile with the rung's name but other settings")
+	}
+}
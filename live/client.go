@@ -2,9 +2,12 @@ package live
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pandastream/go-panda"
@@ -12,10 +15,39 @@ import (
 
 type Client struct {
 	Client *panda.Client
+	// Signer signs the URLs of playback endpoints which require a signature
+	Signer PlaybackSigner
 }
 
-func (cl *Client) get(path string, v interface{}) error {
-	b, err := cl.Client.Get(path, nil)
+// WithContext returns a client whose calls carry the given context
+func (cl *Client) WithContext(ctx context.Context) *Client {
+	return &Client{Client: cl.Client.WithContext(ctx), Signer: cl.Signer}
+}
+
+// pathFormats lists all path formats used by the Client, more specific ones first
+var pathFormats = []string{
+	"/v2/profiles.json",
+	"/v2/profiles/%s.json",
+	"/v2/streams.json",
+	"/v2/streams/profile.json",
+	"/v2/streams/%s/duration.json",
+	"/v2/streams/%s.json",
+}
+
+func (cl *Client) do(op, method, path, cntType string, params url.Values, r io.Reader) ([]byte, error) {
+	return cl.Client.Do(&panda.Call{
+		Operation:   "live." + op,
+		Method:      method,
+		Path:        path,
+		Template:    panda.PathTemplate(path, pathFormats...),
+		ContentType: cntType,
+		Params:      params,
+		Body:        r,
+	})
+}
+
+func (cl *Client) get(op, path string, v interface{}) error {
+	b, err := cl.do(op, "GET", path, "", nil, nil)
 	if err != nil {
 		return err
 	}
@@ -23,7 +55,7 @@ func (cl *Client) get(path string, v interface{}) error {
 }
 
 func (cl *Client) ProfilesIDs() (ids []string, err error) {
-	if err := cl.get("/v2/profiles.json", &ids); err != nil {
+	if err := cl.get("ProfilesIDs", "/v2/profiles.json", &ids); err != nil {
 		return nil, err
 	}
 	return ids, nil
@@ -31,7 +63,7 @@ func (cl *Client) ProfilesIDs() (ids []string, err error) {
 
 func (cl *Client) Profile(id string) (*Profile, error) {
 	profile := Profile{}
-	if err := cl.get(fmt.Sprintf("/v2/profiles/%s.json", id), &profile); err != nil {
+	if err := cl.get("Profile", fmt.Sprintf("/v2/profiles/%s.json", id), &profile); err != nil {
 		return nil, err
 	}
 	return &profile, nil
@@ -42,7 +74,7 @@ func (cl *Client) ProfileCreate(p *Profile) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	b, err = cl.Client.Post("/v2/profiles.json", "application/json", nil, bytes.NewReader(b))
+	b, err = cl.do("ProfileCreate", "POST", "/v2/profiles.json", "application/json", nil, bytes.NewReader(b))
 	if err != nil {
 		return "", err
 	}
@@ -54,12 +86,12 @@ func (cl *Client) ProfileCreate(p *Profile) (string, error) {
 }
 
 func (cl *Client) ProfileDelete(id string) error {
-	_, err := cl.Client.Delete(fmt.Sprintf("/v2/profiles/%s.json", id))
+	_, err := cl.do("ProfileDelete", "DELETE", fmt.Sprintf("/v2/profiles/%s.json", id), "", nil, nil)
 	return err
 }
 
 func (cl *Client) StreamsIDs() (ids []string, err error) {
-	if err := cl.get("/v2/streams.json", &ids); err != nil {
+	if err := cl.get("StreamsIDs", "/v2/streams.json", &ids); err != nil {
 		return nil, err
 	}
 	return ids, nil
@@ -67,7 +99,7 @@ func (cl *Client) StreamsIDs() (ids []string, err error) {
 
 func (cl *Client) Stream(id string) (*Stream, error) {
 	stream := Stream{}
-	if err := cl.get(fmt.Sprintf("/v2/streams/%s.json", id), &stream); err != nil {
+	if err := cl.get("Stream", fmt.Sprintf("/v2/streams/%s.json", id), &stream); err != nil {
 		return nil, err
 	}
 	return &stream, nil
@@ -78,7 +110,7 @@ func (cl *Client) StreamCreate(s *Stream) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	b, err = cl.Client.Post("/v2/streams.json", "application/json", nil, bytes.NewReader(b))
+	b, err = cl.do("StreamCreate", "POST", "/v2/streams.json", "application/json", nil, bytes.NewReader(b))
 	if err != nil {
 		return "", err
 	}
@@ -94,7 +126,7 @@ func (cl *Client) StreamCreateProfile(p *Profile) (streamID, profileID string, e
 	if err != nil {
 		return "", "", err
 	}
-	b, err = cl.Client.Post("/v2/streams/profile.json", "application/json", nil, bytes.NewReader(b))
+	b, err = cl.do("StreamCreateProfile", "POST", "/v2/streams/profile.json", "application/json", nil, bytes.NewReader(b))
 	if err != nil {
 		return "", "", err
 	}
@@ -105,10 +137,12 @@ func (cl *Client) StreamCreateProfile(p *Profile) (streamID, profileID string, e
 	return resp.StreamID, resp.ProfileID, nil
 }
 
+// StreamDuration sets the total duration of the stream with the given id. Panda
+// counts stream durations in whole minutes, so dur is rounded up to the next minute.
 func (cl *Client) StreamDuration(id string, dur time.Duration) (streamID string, err error) {
 	v := url.Values{}
-	v.Add("duration", dur.String())
-	b, err := cl.Client.Put(fmt.Sprintf("/v2/streams/%s/duration.json", id), "application/json", v, nil)
+	v.Add("duration", strconv.Itoa(Minutes(dur)))
+	b, err := cl.do("StreamDuration", "PUT", fmt.Sprintf("/v2/streams/%s/duration.json", id), "application/json", v, nil)
 	if err != nil {
 		return "", err
 	}
@@ -120,6 +154,6 @@ func (cl *Client) StreamDuration(id string, dur time.Duration) (streamID string,
 }
 
 func (cl *Client) StreamDelete(id string) error {
-	_, err := cl.Client.Delete(fmt.Sprintf("/v2/streams/%s.json", id))
+	_, err := cl.do("StreamDelete", "DELETE", fmt.Sprintf("/v2/streams/%s.json", id), "", nil, nil)
 	return err
 }
@@ -0,0 +1,190 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node types used by Panda live profiles
+const (
+	NodeRTMPIngest = "rtmp_ingest"
+	NodeHLS        = "hls"
+	NodeHLSMaster  = "hls_master"
+)
+
+// IngestEndpoint describes where a broadcaster should publish the stream to
+type IngestEndpoint struct {
+	Node string
+	// URL is the RTMP publish URL without the stream key
+	URL       string
+	StreamKey string
+}
+
+// PublishURL returns the full RTMP URL including the stream key
+func (e IngestEndpoint) PublishURL() string {
+	if e.StreamKey == "" {
+		return e.URL
+	}
+	return strings.TrimSuffix(e.URL, "/") + "/" + e.StreamKey
+}
+
+// PlaybackEndpoint describes an HLS playlist viewers can play the stream from
+type PlaybackEndpoint struct {
+	Node string
+	Type string
+	URL  string
+	// Bandwidth is taken from the node's config and is only set for variants
+	Bandwidth int
+	// Signed is true when the profile requires playback URLs to be signed
+	Signed bool
+}
+
+// Master reports whether the endpoint is an HLS master playlist
+func (e PlaybackEndpoint) Master() bool {
+	return e.Type == NodeHLSMaster
+}
+
+// Endpoints holds a stream's endpoints split by their purpose
+type Endpoints struct {
+	Ingest   []IngestEndpoint
+	Playback []PlaybackEndpoint
+}
+
+// Master returns the first HLS master playlist endpoint
+func (e *Endpoints) Master() (PlaybackEndpoint, bool) {
+	for _, p := range e.Playback {
+		if p.Master() {
+			return p, true
+		}
+	}
+	return PlaybackEndpoint{}, false
+}
+
+// Variants returns all HLS variant endpoints ordered by bandwidth
+func (e *Endpoints) Variants() []PlaybackEndpoint {
+	var vs []PlaybackEndpoint
+	for _, p := range e.Playback {
+		if !p.Master() {
+			vs = append(vs, p)
+		}
+	}
+	sort.SliceStable(vs, func(i, j int) bool { return vs[i].Bandwidth < vs[j].Bandwidth })
+	return vs
+}
+
+// ParseEndpoints matches the stream's endpoints with the nodes of the given profile,
+// which must be the profile the stream was created from. Endpoints which do not
+// belong to any of the profile's nodes are reported as an error, endpoints of
+// node types other than RTMP ingest and HLS are skipped.
+func (s *Stream) ParseEndpoints(p *Profile) (*Endpoints, error) {
+	if p.ProfileID != "" && s.ProfileID != "" && p.ProfileID != s.ProfileID {
+		return nil, fmt.Errorf("live: stream %s was not created from profile %s", s.StreamID, p.ProfileID)
+	}
+	names := make([]string, 0, len(s.Endpoints))
+	for name := range s.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	eps := &Endpoints{}
+	for _, name := range names {
+		raw := s.Endpoints[name]
+		node, ok := p.Nodes[name]
+		if !ok {
+			return nil, fmt.Errorf("live: endpoint %q has no matching profile node", name)
+		}
+		switch node.Type {
+		case NodeRTMPIngest:
+			ep, err := parseIngest(name, raw)
+			if err != nil {
+				return nil, err
+			}
+			eps.Ingest = append(eps.Ingest, ep)
+		case NodeHLS, NodeHLSMaster:
+			eps.Playback = append(eps.Playback, PlaybackEndpoint{
+				Node:      name,
+				Type:      node.Type,
+				URL:       raw,
+				Bandwidth: configInt(node.Config, "bandwidth"),
+				Signed:    configBool(node.Config, "signed"),
+			})
+		}
+	}
+	return eps, nil
+}
+
+func parseIngest(name, raw string) (IngestEndpoint, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return IngestEndpoint{}, err
+	}
+	if u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return IngestEndpoint{}, fmt.Errorf("live: endpoint %q is not an RTMP URL: %s", name, raw)
+	}
+	dir, key := path.Split(strings.TrimSuffix(u.Path, "/"))
+	ep := IngestEndpoint{Node: name, StreamKey: key}
+	u.Path = strings.TrimSuffix(dir, "/")
+	ep.URL = u.String()
+	return ep, nil
+}
+
+func configInt(cfg map[string]interface{}, key string) int {
+	switch v := cfg[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+func configBool(cfg map[string]interface{}, key string) bool {
+	switch v := cfg[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// ErrNoPlaybackSigner is returned when a playback URL requires a signature but
+// the client has no Signer.
+var ErrNoPlaybackSigner = errors.New("live: endpoint requires a signed URL but the client has no signer")
+
+// PlaybackSigner produces time-limited playback URLs, e.g. as required by the CDN
+// serving the stream's HLS nodes
+type PlaybackSigner interface {
+	SignPlaybackURL(e PlaybackEndpoint, expires time.Time) (string, error)
+}
+
+// PlaybackSignerFunc is an adapter which allows an ordinary function to be used as
+// a PlaybackSigner
+type PlaybackSignerFunc func(e PlaybackEndpoint, expires time.Time) (string, error)
+
+// SignPlaybackURL calls f(e, expires)
+func (f PlaybackSignerFunc) SignPlaybackURL(e PlaybackEndpoint, expires time.Time) (string, error) {
+	return f(e, expires)
+}
+
+// SignPlaybackURL returns the URL the endpoint is played from until expires.
+// Unsigned endpoints are returned unchanged, signed ones are passed to the
+// client's Signer.
+func (cl *Client) SignPlaybackURL(e PlaybackEndpoint, expires time.Time) (string, error) {
+	if !e.Signed {
+		return e.URL, nil
+	}
+	if cl.Signer == nil {
+		return "", ErrNoPlaybackSigner
+	}
+	return cl.Signer.SignPlaybackURL(e, expires)
+}
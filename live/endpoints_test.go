@@ -0,0 +1,105 @@
+package live
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pandastream/go-panda"
+)
+
+var testProfile = &Profile{
+	ProfileID: "p1",
+	Nodes: Nodes{
+		"ingester": Node{Type: NodeRTMPIngest},
+		"hls_low": Node{Type: NodeHLS, Config: map[string]interface{}{
+			"bandwidth": float64(1000),
+		}},
+		"hls_high": Node{Type: NodeHLS, Config: map[string]interface{}{
+			"bandwidth": float64(3000),
+		}},
+		"hls_top": Node{Type: NodeHLSMaster, Config: map[string]interface{}{
+			"signed": true,
+		}},
+		"recorder": Node{Type: "recorder"},
+	},
+}
+
+func TestParseEndpoints(t *testing.T) {
+	s := &Stream{
+		StreamID:  "s1",
+		ProfileID: "p1",
+		Endpoints: map[string]string{
+			"ingester": "rtmp://live.example.com/in/abc123",
+			"hls_low":  "https://cdn.example.com/hello1/index.m3u8",
+			"hls_high": "https://cdn.example.com/hello2/index.m3u8",
+			"hls_top":  "https://cdn.example.com/hello/master.m3u8",
+			"recorder": "s3://bucket/hello",
+		},
+	}
+	eps, err := s.ParseEndpoints(testProfile)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	expIngest := []IngestEndpoint{{
+		Node:      "ingester",
+		URL:       "rtmp://live.example.com/in",
+		StreamKey: "abc123",
+	}}
+	if !reflect.DeepEqual(eps.Ingest, expIngest) {
+		t.Errorf("want ingest=%#v; got %#v", expIngest, eps.Ingest)
+	}
+	if u := eps.Ingest[0].PublishURL(); u != s.Endpoints["ingester"] {
+		t.Errorf("want publish url=%s; got %s", s.Endpoints["ingester"], u)
+	}
+	master, ok := eps.Master()
+	if !ok || master.Node != "hls_top" || !master.Signed {
+		t.Errorf("want master node=hls_top; got %#v", master)
+	}
+	vs := eps.Variants()
+	if len(vs) != 2 || vs[0].Node != "hls_low" || vs[1].Node != "hls_high" {
+		t.Errorf("want variants ordered by bandwidth; got %#v", vs)
+	}
+	if vs[0].Signed {
+		t.Errorf("want variant unsigned; got %#v", vs[0])
+	}
+	if n := len(eps.Ingest) + len(eps.Playback); n != 4 {
+		t.Errorf("want recorder endpoint skipped; got %d endpoints", n)
+	}
+}
+
+func TestParseEndpointsUnknownNode(t *testing.T) {
+	s := &Stream{Endpoints: map[string]string{"other": "https://example.com/x.m3u8"}}
+	if _, err := s.ParseEndpoints(testProfile); err == nil {
+		t.Error("want err!=nil; got nil")
+	}
+}
+
+func TestSignPlaybackURL(t *testing.T) {
+	cl := &Client{Client: &panda.Client{}}
+	exp := time.Unix(1500000000, 0)
+	ep := PlaybackEndpoint{Node: "hls_low", URL: "https://cdn.example.com/hello1/index.m3u8"}
+	if u, err := cl.SignPlaybackURL(ep, exp); err != nil || u != ep.URL {
+		t.Errorf("want unsigned url=%s; got %s (err=%v)", ep.URL, u, err)
+	}
+	ep.Signed = true
+	if _, err := cl.SignPlaybackURL(ep, exp); err != ErrNoPlaybackSigner {
+		t.Errorf("want err=%v; got %v", ErrNoPlaybackSigner, err)
+	}
+	cl.Signer = PlaybackSignerFunc(func(e PlaybackEndpoint, expires time.Time) (string, error) {
+		return e.URL + "?node=" + e.Node + "&expires=" + strconv.FormatInt(expires.Unix(), 10), nil
+	})
+	u, err := cl.WithContext(context.Background()).SignPlaybackURL(ep, exp)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if s := ep.URL + "?node=hls_low&expires=1500000000"; u != s {
+		t.Errorf("want url=%s; got %s", s, u)
+	}
+	ep.Signed = false
+	if u, err := cl.SignPlaybackURL(ep, exp); err != nil || u != ep.URL {
+		t.Errorf("want unsigned url=%s; got %s (err=%v)", ep.URL, u, err)
+	}
+}
@@ -70,7 +70,7 @@ func ExampleClient_streamCreate() {
 
 	stream := &live.Stream{
 		ProfileID: "999999", // existing profile_id
-		Duration:  10,       // 10 minutes
+		Duration:  live.Minutes(10 * time.Minute),
 	}
 
 	streamID, err := client.StreamCreate(stream)
@@ -147,3 +147,27 @@ func ExampleClient_streamDuration() {
 	}
 	fmt.Println(streamID)
 }
+
+func ExampleClient_streamSchedule() {
+	client := live.Client{
+		Client: &panda.Client{
+			Host: panda.HostGCE,
+			Options: &panda.ClientOptions{
+				AccessKey: "access_key",
+				SecretKey: "secret_key",
+				CloudID:   "cloud_id",
+				Namespace: "live",
+			},
+		},
+	}
+
+	start := time.Now().Add(time.Hour)
+	streamID, err := client.StreamSchedule("999999", start, 30*time.Minute)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = client.StreamExtend(streamID, 15*time.Minute); err != nil {
+		panic(err)
+	}
+	fmt.Println(streamID)
+}
@@ -0,0 +1,242 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pandastream/go-panda/hls"
+)
+
+// Health describes whether a running stream keeps producing output
+type Health uint8
+
+const (
+	HealthUnknown  = Health(0)
+	HealthHealthy  = Health(1)
+	HealthDegraded = Health(2)
+	HealthStalled  = Health(3)
+)
+
+var healthNames = map[Health]string{
+	HealthUnknown:  "unknown",
+	HealthHealthy:  "healthy",
+	HealthDegraded: "degraded",
+	HealthStalled:  "stalled",
+}
+
+func (h Health) String() string {
+	return healthNames[h]
+}
+
+// HealthEvent is emitted by the Monitor whenever a stream's health changes
+type HealthEvent struct {
+	StreamID string
+	Health   Health
+	Previous Health
+	Time     time.Time
+	// Reason is a human readable explanation of the health
+	Reason string
+	// LastSegment is when a new segment was last seen in the media playlist
+	LastSegment time.Time
+	// Sequence is the media sequence number of the newest segment
+	Sequence int
+	CPU      int
+	// Err is set if the stream or its playlist could not be fetched or found
+	Err error
+}
+
+// AlertSink receives health events from the Monitor
+type AlertSink interface {
+	Alert(HealthEvent)
+}
+
+// AlertFunc is an adapter which allows an ordinary function to be used as an AlertSink
+type AlertFunc func(HealthEvent)
+
+// Alert calls f(e)
+func (f AlertFunc) Alert(e HealthEvent) {
+	f(e)
+}
+
+// Monitor periodically checks whether a live stream in progress keeps publishing
+// new HLS segments. A stream whose newest segment is older than DegradedAfter
+// target durations is degraded and one whose newest segment is older than
+// StalledAfter target durations is stalled, playlists without a target duration
+// are assumed to have one of 6 seconds. A stream using more than MaxCPU is
+// reported as degraded as well.
+type Monitor struct {
+	Client *Client
+	// HLS is used to fetch the stream's playlists, a default hls.Client is used if nil
+	HLS  *hls.Client
+	Sink AlertSink
+	// Interval between checks, defaults to 5 seconds
+	Interval time.Duration
+	// DegradedAfter and StalledAfter default to 2 and 4 target durations
+	DegradedAfter float64
+	StalledAfter  float64
+	// MaxCPU is ignored if zero
+	MaxCPU int
+}
+
+type monitorState struct {
+	id          string
+	media       *PlaybackEndpoint
+	sequence    int
+	lastSegment time.Time
+	health      Health
+}
+
+func (m *Monitor) hls() *hls.Client {
+	if m.HLS != nil {
+		return m.HLS
+	}
+	return &hls.Client{}
+}
+
+func (m *Monitor) interval() time.Duration {
+	if m.Interval > 0 {
+		return m.Interval
+	}
+	return 5 * time.Second
+}
+
+func (m *Monitor) windows() (degraded, stalled float64) {
+	degraded, stalled = m.DegradedAfter, m.StalledAfter
+	if degraded <= 0 {
+		degraded = 2
+	}
+	if stalled <= 0 {
+		stalled = 4
+	}
+	return
+}
+
+// Run monitors the stream with the given id until it finishes or ctx is done. Events
+// are sent to the Sink every time the stream's health changes. Run returns nil once
+// the stream has finished and ctx.Err() when ctx is done.
+func (m *Monitor) Run(ctx context.Context, id string) error {
+	st := &monitorState{id: id, sequence: -1}
+	t := time.NewTicker(m.interval())
+	defer t.Stop()
+	for {
+		done, err := m.check(ctx, st, time.Now())
+		if err != nil || done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// defaultTargetDuration is assumed for playlists which do not declare one
+const defaultTargetDuration = 6 * time.Second
+
+func (m *Monitor) check(ctx context.Context, st *monitorState, now time.Time) (done bool, err error) {
+	cl := m.Client.WithContext(ctx)
+	s, err := cl.Stream(st.id)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		m.report(st, HealthEvent{Health: HealthDegraded, Reason: "fetching stream failed", Err: err}, now)
+		return false, nil
+	}
+	if s.Finished() {
+		return true, nil
+	}
+	if s.Status != StateInProgress {
+		return false, nil
+	}
+	if st.media == nil {
+		if st.media, err = m.mediaEndpoint(ctx, s); err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			m.report(st, HealthEvent{Health: HealthDegraded, Reason: "finding playlist failed", Err: err, CPU: s.CPU}, now)
+			return false, nil
+		}
+	}
+	u, err := cl.SignPlaybackURL(*st.media, now.Add(m.interval()+time.Minute))
+	if err != nil {
+		return false, err
+	}
+	p, err := m.hls().WithContext(ctx).Media(u)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		m.report(st, HealthEvent{Health: HealthDegraded, Reason: "fetching playlist failed", Err: err, CPU: s.CPU}, now)
+		return false, nil
+	}
+	if seq := p.LastSequence(); seq > st.sequence {
+		st.sequence, st.lastSegment = seq, now
+	} else if st.lastSegment.IsZero() {
+		st.lastSegment = now
+	}
+	degraded, stalled := m.windows()
+	target := p.TargetDuration
+	if target <= 0 {
+		target = defaultTargetDuration
+	}
+	age := now.Sub(st.lastSegment)
+	e := HealthEvent{Health: HealthHealthy, CPU: s.CPU}
+	switch {
+	case p.Ended:
+		return true, nil
+	case age > time.Duration(stalled*float64(target)):
+		e.Health, e.Reason = HealthStalled, fmt.Sprintf("no new segment for %v", age)
+	case age > time.Duration(degraded*float64(target)):
+		e.Health, e.Reason = HealthDegraded, fmt.Sprintf("no new segment for %v", age)
+	case m.MaxCPU > 0 && s.CPU > m.MaxCPU:
+		e.Health, e.Reason = HealthDegraded, fmt.Sprintf("cpu %d exceeds %d", s.CPU, m.MaxCPU)
+	}
+	m.report(st, e, now)
+	return false, nil
+}
+
+func (m *Monitor) report(st *monitorState, e HealthEvent, now time.Time) {
+	if e.Health == st.health {
+		return
+	}
+	e.StreamID, e.Previous, e.Time = st.id, st.health, now
+	e.LastSegment, e.Sequence = st.lastSegment, st.sequence
+	st.health = e.Health
+	if m.Sink != nil {
+		m.Sink.Alert(e)
+	}
+}
+
+// mediaEndpoint finds the media playlist to watch, which is the lowest bandwidth variant
+func (m *Monitor) mediaEndpoint(ctx context.Context, s *Stream) (*PlaybackEndpoint, error) {
+	cl := m.Client.WithContext(ctx)
+	p, err := cl.Profile(s.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	eps, err := s.ParseEndpoints(p)
+	if err != nil {
+		return nil, err
+	}
+	if vs := eps.Variants(); len(vs) > 0 {
+		return &vs[0], nil
+	}
+	if master, ok := eps.Master(); ok {
+		u, err := cl.SignPlaybackURL(master, time.Now().Add(time.Minute))
+		if err != nil {
+			return nil, err
+		}
+		mp, err := m.hls().WithContext(ctx).Master(u)
+		if err != nil {
+			return nil, err
+		}
+		if len(mp.Variants) > 0 {
+			return &PlaybackEndpoint{Node: master.Node, Type: NodeHLS, URL: mp.Variants[0].URI}, nil
+		}
+	}
+	return nil, errors.New("live: stream has no HLS playback endpoint")
+}
@@ -0,0 +1,203 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitorCheck(t *testing.T) {
+	sequence, cpu := 0, 10
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/live/v2/streams/"):
+			v = &Stream{
+				StreamID:  "s1",
+				ProfileID: "p1",
+				Status:    StateInProgress,
+				CPU:       cpu,
+				Endpoints: map[string]string{
+					"ingester": "rtmp://localhost/in/key",
+					"hls_low":  ts.URL + "/hello1/index.m3u8",
+				},
+			}
+		case strings.HasPrefix(r.URL.Path, "/live/v2/profiles/"):
+			v = &Profile{ProfileID: "p1", Nodes: Nodes{
+				"ingester": Node{Type: NodeRTMPIngest},
+				"hls_low":  Node{Type: NodeHLS},
+			}}
+		case r.URL.Path == "/hello1/index.m3u8":
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:%d\n#EXTINF:2,\nseg.ts\n", sequence)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := json.Marshal(v)
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	var events []Health
+	m := &Monitor{
+		Client: newClient(ts.URL, t),
+		Sink:   AlertFunc(func(e HealthEvent) { events = append(events, e.Health) }),
+		MaxCPU: 50,
+	}
+	st := &monitorState{id: "s1", sequence: -1}
+	now := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after    time.Duration
+		sequence int
+		cpu      int
+	}{
+		{0, 0, 10},
+		{time.Second, 0, 10},
+		{4 * time.Second, 0, 10},
+		{4 * time.Second, 0, 10},
+		{time.Second, 1, 10},
+		{time.Second, 2, 90},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		sequence, cpu = step.sequence, step.cpu
+		if done, err := m.check(context.Background(), st, now); err != nil || done {
+			t.Fatalf("want done=false, err=nil; got %v, %v (i=%d)", done, err, i)
+		}
+	}
+	exp := []Health{HealthHealthy, HealthDegraded, HealthStalled, HealthHealthy, HealthDegraded}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("want events=%v; got %v", exp, events)
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	var mu sync.Mutex
+	streams, profiles := 0, 0
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var v interface{}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/live/v2/streams/s1"):
+			streams++
+			status := StateInProgress
+			if streams > 3 {
+				status = StateEnded
+			}
+			v = &Stream{StreamID: "s1", ProfileID: "p1", Status: status,
+				Endpoints: map[string]string{"hls_low": ts.URL + "/hello1/index.m3u8"}}
+		case strings.HasPrefix(r.URL.Path, "/live/v2/streams/"):
+			v = &Stream{StreamID: "s2", ProfileID: "p1", Status: StateInProgress}
+		case strings.HasPrefix(r.URL.Path, "/live/v2/profiles/"):
+			if profiles++; profiles == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			v = &Profile{ProfileID: "p1", Nodes: Nodes{"hls_low": Node{Type: NodeHLS}}}
+		case r.URL.Path == "/hello1/index.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:0\n#EXTINF:2,\nseg.ts\n")
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b, _ := json.Marshal(v)
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	var events []HealthEvent
+	m := &Monitor{
+		Client:   newClient(ts.URL, t),
+		Sink:     AlertFunc(func(e HealthEvent) { events = append(events, e) }),
+		Interval: time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Run(ctx, "s1"); err != nil {
+		t.Fatalf("want err=nil once the stream finished; got %v", err)
+	}
+	if len(events) != 2 || events[0].Health != HealthDegraded || events[0].Err == nil ||
+		events[1].Health != HealthHealthy {
+		t.Errorf("want degraded then healthy; got %+v", events)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx, "s2"); err != context.DeadlineExceeded {
+		t.Errorf("want err=%v; got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestMonitorNoTargetDuration(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/live/v2/streams/"):
+			v = &Stream{StreamID: "s1", ProfileID: "p1", Status: StateInProgress,
+				Endpoints: map[string]string{"hls_low": ts.URL + "/hello1/index.m3u8"}}
+		case strings.HasPrefix(r.URL.Path, "/live/v2/profiles/"):
+			v = &Profile{ProfileID: "p1", Nodes: Nodes{"hls_low": Node{Type: NodeHLS}}}
+		default:
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:0\n#EXTINF:2,\nseg.ts\n")
+			return
+		}
+		b, _ := json.Marshal(v)
+		w.Write(b)
+	}))
+	defer ts.Close()
+	var events []Health
+	m := &Monitor{
+		Client: newClient(ts.URL, t),
+		Sink:   AlertFunc(func(e HealthEvent) { events = append(events, e.Health) }),
+	}
+	st := &monitorState{id: "s1", sequence: -1}
+	now := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, after := range []time.Duration{0, 4 * time.Second, 9 * time.Second} {
+		now = now.Add(after)
+		if _, err := m.check(context.Background(), st, now); err != nil {
+			t.Fatalf("want err=nil; got %v (i=%d)", err, i)
+		}
+	}
+	if exp := []Health{HealthHealthy, HealthDegraded}; !reflect.DeepEqual(events, exp) {
+		t.Errorf("want events=%v; got %v", exp, events)
+	}
+}
+
+func TestMonitorRunCancelsProbe(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	var events []HealthEvent
+	m := &Monitor{
+		Client: newClient(ts.URL, t),
+		Sink:   AlertFunc(func(e HealthEvent) { events = append(events, e) }),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := m.Run(ctx, "s1"); err != context.DeadlineExceeded {
+		t.Errorf("want err=%v; got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("want the probe to be cancelled; took %v", d)
+	}
+	if len(events) != 0 {
+		t.Errorf("want no events for a cancelled probe; got %+v", events)
+	}
+}
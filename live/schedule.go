@@ -0,0 +1,152 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStreamFinished is returned when a duration change is requested for a stream
+// that has already ended or failed.
+var ErrStreamFinished = errors.New("live: stream has already finished")
+
+// Minutes converts d to the number of minutes Panda expects in Stream.Duration
+// and Profile.Duration. Partial minutes are rounded up and negative durations
+// are treated as zero.
+func Minutes(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Minute - 1) / time.Minute)
+}
+
+// Length returns the total duration of the stream.
+func (s *Stream) Length() time.Duration {
+	return time.Duration(s.Duration) * time.Minute
+}
+
+// Finished reports whether the stream has ended, either normally or with an error.
+func (s *Stream) Finished() bool {
+	return s.Status == StateEnded || s.Status == StateError
+}
+
+// EndsAt returns the time at which the stream is going to be stopped. It is
+// computed from StartedAt for running streams and from ScheduledAt for streams
+// which have not started yet. The returned bool is false when neither is known.
+func (s *Stream) EndsAt() (time.Time, bool) {
+	switch {
+	case s.EndedAt != nil:
+		return *s.EndedAt, true
+	case s.StartedAt != nil:
+		return s.StartedAt.Add(s.Length()), true
+	case s.ScheduledAt != nil:
+		return s.ScheduledAt.Add(s.Length()), true
+	}
+	return time.Time{}, false
+}
+
+// Remaining returns how much time is left until the stream ends, as seen at now.
+// Streams which have not been started or scheduled have their full length
+// remaining, finished streams have none.
+func (s *Stream) Remaining(now time.Time) time.Duration {
+	if s.Finished() {
+		return 0
+	}
+	end, ok := s.EndsAt()
+	if !ok {
+		return s.Length()
+	}
+	if rem := end.Sub(now); rem > 0 {
+		return rem
+	}
+	return 0
+}
+
+// StreamSchedule creates a new stream for the existing profile which is going to
+// start at the given time and last for dur.
+func (cl *Client) StreamSchedule(profileID string, at time.Time, dur time.Duration) (string, error) {
+	at = at.UTC()
+	return cl.StreamCreate(&Stream{
+		ProfileID:   profileID,
+		ScheduledAt: &at,
+		Duration:    Minutes(dur),
+	})
+}
+
+// StreamExtend changes the duration of the stream with the given id by d. A negative
+// d shortens the stream, but never below the time it has already been running.
+// Shortening a stream which has not started to nothing is an error. It returns
+// the stream's new total duration.
+func (cl *Client) StreamExtend(id string, d time.Duration) (time.Duration, error) {
+	s, err := cl.Stream(id)
+	if err != nil {
+		return 0, err
+	}
+	return cl.extend(id, s, d)
+}
+
+func (cl *Client) extend(id string, s *Stream, d time.Duration) (time.Duration, error) {
+	if s.Finished() {
+		return 0, ErrStreamFinished
+	}
+	total := s.Length() + d
+	if s.StartedAt != nil {
+		if elapsed := time.Since(*s.StartedAt); total < elapsed {
+			total = elapsed
+		}
+	} else if total <= 0 {
+		return 0, fmt.Errorf("live: stream %s cannot be shortened by %v to %v", id, -d, total)
+	}
+	if _, err := cl.StreamDuration(id, total); err != nil {
+		return 0, err
+	}
+	return time.Duration(Minutes(total)) * time.Minute, nil
+}
+
+// StreamRemaining returns how much time is left until the stream with the given
+// id ends.
+func (cl *Client) StreamRemaining(id string) (time.Duration, error) {
+	s, err := cl.Stream(id)
+	if err != nil {
+		return 0, err
+	}
+	return s.Remaining(time.Now()), nil
+}
+
+// AutoExtend keeps the stream with the given id running for as long as cond
+// returns true. Every interval the stream is fetched and, if less than step is
+// remaining and cond holds, its duration is extended by step. AutoExtend returns
+// nil once the stream finishes or cond returns false, and ctx.Err() when ctx is
+// done. The interval must be positive.
+func (cl *Client) AutoExtend(ctx context.Context, id string, step, interval time.Duration,
+	cond func(*Stream) bool) error {
+	if interval <= 0 {
+		return fmt.Errorf("live: invalid AutoExtend interval %v", interval)
+	}
+	if step < time.Minute {
+		step = time.Minute
+	}
+	cl = cl.WithContext(ctx)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s, err := cl.Stream(id)
+		if err != nil {
+			return err
+		}
+		if s.Finished() || !cond(s) {
+			return nil
+		}
+		if s.Remaining(time.Now()) < step {
+			if _, err := cl.extend(id, s, step); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pandastream/go-panda"
+)
+
+func newClient(addr string, t *testing.T) *Client {
+	URL, err := url.Parse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{
+		Client: &panda.Client{
+			Host: URL.Host,
+			Options: &panda.ClientOptions{
+				CloudID:   "1",
+				AccessKey: "2",
+				SecretKey: "3",
+				Namespace: "live",
+			},
+		},
+	}
+}
+
+func TestMinutes(t *testing.T) {
+	cases := []struct {
+		d   time.Duration
+		exp int
+	}{
+		{0, 0},
+		{-time.Minute, 0},
+		{time.Second, 1},
+		{5 * time.Minute, 5},
+		{5*time.Minute + time.Second, 6},
+	}
+	for i, cas := range cases {
+		if res := Minutes(cas.d); res != cas.exp {
+			t.Errorf("want minutes=%d; got %d (i=%d)", cas.exp, res, i)
+		}
+	}
+}
+
+func TestStreamRemaining(t *testing.T) {
+	now := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	started := now.Add(-10 * time.Minute)
+	scheduled := now.Add(time.Hour)
+	cases := []struct {
+		s   Stream
+		exp time.Duration
+	}{
+		{Stream{Duration: 30, Status: StateNew}, 30 * time.Minute},
+		{Stream{Duration: 30, StartedAt: &started, Status: StateInProgress}, 20 * time.Minute},
+		{Stream{Duration: 5, StartedAt: &started, Status: StateInProgress}, 0},
+		{Stream{Duration: 30, ScheduledAt: &scheduled, Status: StateQueued}, 90 * time.Minute},
+		{Stream{Duration: 30, StartedAt: &started, Status: StateEnded}, 0},
+	}
+	for i, cas := range cases {
+		if res := cas.s.Remaining(now); res != cas.exp {
+			t.Errorf("want remaining=%v; got %v (i=%d)", cas.exp, res, i)
+		}
+	}
+}
+
+func TestStreamDuration(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("duration")
+		w.Write([]byte(`{"stream_id":"1"}`))
+	}))
+	defer ts.Close()
+	cl := newClient(ts.URL, t)
+	if _, err := cl.StreamDuration("1", 5*time.Minute); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if got != "5" {
+		t.Errorf("want duration=5; got %s", got)
+	}
+}
+
+func TestAutoExtend(t *testing.T) {
+	var mu sync.Mutex
+	started := time.Now().Add(-9 * time.Minute)
+	stream := Stream{StreamID: "1", Duration: 10, StartedAt: &started, Status: StateInProgress}
+	extended := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/duration.json"):
+			extended++
+			if err := json.Unmarshal([]byte(r.URL.Query().Get("duration")), &stream.Duration); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"stream_id":"1"}`))
+		default:
+			b, _ := json.Marshal(&stream)
+			w.Write(b)
+		}
+	}))
+	defer ts.Close()
+	cl := newClient(ts.URL, t)
+	calls := 0
+	err := cl.AutoExtend(context.Background(), "1", 5*time.Minute, time.Millisecond, func(*Stream) bool {
+		calls++
+		return calls < 3
+	})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if extended != 1 {
+		t.Errorf("want 1 extension; got %d", extended)
+	}
+	if stream.Duration != 15 {
+		t.Errorf("want duration=15; got %d", stream.Duration)
+	}
+}
+
+func TestAutoExtendCancel(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	cl := newClient(ts.URL, t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- cl.AutoExtend(ctx, "1", time.Minute, time.Second, func(*Stream) bool { return true })
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("want err!=nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("want AutoExtend to abort the pending request when ctx is done")
+	}
+}
+
+func TestStreamExtendInvalid(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"stream_id":"1"}`))
+	}))
+	defer ts.Close()
+	cl := newClient(ts.URL, t)
+	scheduled := time.Now().Add(time.Hour)
+	s := &Stream{StreamID: "1", Duration: 10, ScheduledAt: &scheduled, Status: StateQueued}
+	for i, d := range []time.Duration{-10 * time.Minute, -time.Hour} {
+		if _, err := cl.extend("1", s, d); err == nil {
+			t.Errorf("want err!=nil (i=%d)", i)
+		}
+	}
+	if n, err := cl.extend("1", s, -5*time.Minute); err != nil || n != 5*time.Minute {
+		t.Errorf("want duration=5m0s, err=nil; got %v, %v", n, err)
+	}
+	err := cl.AutoExtend(context.Background(), "1", time.Minute, 0, func(*Stream) bool { return true })
+	if err == nil {
+		t.Error("want error for zero interval")
+	}
+	if requests != 1 {
+		t.Errorf("want 1 request; got %d", requests)
+	}
+}
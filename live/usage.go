@@ -0,0 +1,181 @@
+package live
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Usage aggregates stream usage for a single profile or account
+type Usage struct {
+	ID            string  `json:"id"`
+	Streams       int     `json:"streams"`
+	BilledMinutes int     `json:"billed_minutes"`
+	CPUMinutes    float64 `json:"cpu_minutes"`
+}
+
+// UsageReport summarizes usage of all streams which were running within a time range.
+// Only the part of each stream which falls within the range is accounted for.
+type UsageReport struct {
+	From           time.Time  `json:"from"`
+	To             time.Time  `json:"to"`
+	Streams        int        `json:"streams"`
+	BilledMinutes  int        `json:"billed_minutes"`
+	CPUMinutes     float64    `json:"cpu_minutes"`
+	PeakConcurrent int        `json:"peak_concurrent"`
+	PeakAt         *time.Time `json:"peak_at,omitempty"`
+	// Unknown counts finished streams with neither an end time nor a duration,
+	// which are left out of the report
+	Unknown  int     `json:"unknown"`
+	Profiles []Usage `json:"profiles"`
+	Accounts []Usage `json:"accounts"`
+}
+
+// UsageReport fetches all streams and builds a usage report for the given time range
+func (cl *Client) UsageReport(from, to time.Time) (*UsageReport, error) {
+	ids, err := cl.StreamsIDs()
+	if err != nil {
+		return nil, err
+	}
+	streams := make([]Stream, 0, len(ids))
+	for _, id := range ids {
+		s, err := cl.Stream(id)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, *s)
+	}
+	return NewUsageReport(streams, from, to, time.Now()), nil
+}
+
+// NewUsageReport builds a usage report from the given streams. Streams which have
+// not ended yet are accounted for up to now, finished streams without an end time
+// up to the end of their duration. Billed minutes are rounded up per stream.
+func NewUsageReport(streams []Stream, from, to, now time.Time) *UsageReport {
+	r := &UsageReport{From: from, To: to}
+	profiles := map[string]*Usage{}
+	accounts := map[string]*Usage{}
+	type edge struct {
+		t     time.Time
+		delta int
+	}
+	var edges []edge
+	for i := range streams {
+		s := &streams[i]
+		start, end, ok, known := s.window(from, to, now)
+		if !known {
+			r.Unknown++
+		}
+		if !ok {
+			continue
+		}
+		billed := Minutes(end.Sub(start))
+		cpu := float64(s.CPU) * end.Sub(start).Minutes()
+		r.Streams++
+		r.BilledMinutes += billed
+		r.CPUMinutes += cpu
+		for _, u := range []*Usage{usage(profiles, s.ProfileID), usage(accounts, s.AccountID)} {
+			u.Streams++
+			u.BilledMinutes += billed
+			u.CPUMinutes += cpu
+		}
+		edges = append(edges, edge{start, 1}, edge{end, -1})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].t.Equal(edges[j].t) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].t.Before(edges[j].t)
+	})
+	cur := 0
+	for _, e := range edges {
+		if cur += e.delta; cur > r.PeakConcurrent {
+			at := e.t
+			r.PeakConcurrent, r.PeakAt = cur, &at
+		}
+	}
+	r.Profiles = sortedUsage(profiles)
+	r.Accounts = sortedUsage(accounts)
+	return r
+}
+
+// window returns the part of the stream's run time which falls within [from, to).
+// known is false for finished streams whose end cannot be told.
+func (s *Stream) window(from, to, now time.Time) (start, end time.Time, ok, known bool) {
+	if s.StartedAt == nil {
+		return start, end, false, true
+	}
+	start, end = *s.StartedAt, now
+	switch {
+	case s.EndedAt != nil:
+		end = *s.EndedAt
+	case s.Finished() && s.Duration <= 0:
+		return start, end, false, false
+	case s.Finished():
+		if e := start.Add(s.Length()); e.Before(end) {
+			end = e
+		}
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return start, end, end.After(start), true
+}
+
+func usage(m map[string]*Usage, id string) *Usage {
+	u, ok := m[id]
+	if !ok {
+		u = &Usage{ID: id}
+		m[id] = u
+	}
+	return u
+}
+
+func sortedUsage(m map[string]*Usage) []Usage {
+	us := make([]Usage, 0, len(m))
+	for _, u := range m {
+		us = append(us, *u)
+	}
+	sort.Slice(us, func(i, j int) bool { return us[i].ID < us[j].ID })
+	return us
+}
+
+// WriteJSON writes the report as an indented JSON document
+func (r *UsageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per profile and per account followed by a total row.
+// The columns are: kind, id, streams, billed_minutes, cpu_minutes.
+func (r *UsageReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"kind", "id", "streams", "billed_minutes", "cpu_minutes"}}
+	add := func(kind string, u Usage) {
+		rows = append(rows, []string{
+			kind,
+			u.ID,
+			strconv.Itoa(u.Streams),
+			strconv.Itoa(u.BilledMinutes),
+			strconv.FormatFloat(u.CPUMinutes, 'f', 2, 64),
+		})
+	}
+	for _, u := range r.Profiles {
+		add("profile", u)
+	}
+	for _, u := range r.Accounts {
+		add("account", u)
+	}
+	add("total", Usage{Streams: r.Streams, BilledMinutes: r.BilledMinutes, CPUMinutes: r.CPUMinutes})
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
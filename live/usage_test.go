@@ -0,0 +1,84 @@
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewUsageReportFinished(t *testing.T) {
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	started := base.Add(5 * time.Minute)
+	streams := []Stream{
+		{ProfileID: "p1", StartedAt: &started, Duration: 10, Status: StateEnded},
+		{ProfileID: "p1", StartedAt: &started, Status: StateError},
+	}
+	for _, now := range []time.Time{base.Add(30 * time.Minute), base.Add(50 * time.Minute)} {
+		r := NewUsageReport(streams, base, base.Add(time.Hour), now)
+		if r.Streams != 1 || r.BilledMinutes != 10 || r.Unknown != 1 {
+			t.Errorf("want streams=1, billed=10, unknown=1; got %d, %d, %d", r.Streams, r.BilledMinutes, r.Unknown)
+		}
+	}
+	r := NewUsageReport(nil, base, base.Add(time.Hour), base)
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("peak_at")) {
+		t.Errorf("want no peak_at without streams; got %s", b)
+	}
+}
+
+func TestNewUsageReport(t *testing.T) {
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(min int) *time.Time {
+		t := base.Add(time.Duration(min) * time.Minute)
+		return &t
+	}
+	streams := []Stream{
+		{AccountID: "a1", ProfileID: "p1", StartedAt: at(-30), EndedAt: at(10), CPU: 2},
+		{AccountID: "a1", ProfileID: "p2", StartedAt: at(5), EndedAt: at(20), CPU: 1},
+		{AccountID: "a2", ProfileID: "p1", StartedAt: at(8), CPU: 1},
+		{AccountID: "a2", ProfileID: "p1", ScheduledAt: at(40)},
+		{AccountID: "a2", ProfileID: "p2", StartedAt: at(70), EndedAt: at(80)},
+	}
+	r := NewUsageReport(streams, base, base.Add(time.Hour), base.Add(30*time.Minute))
+	if r.Streams != 3 || r.BilledMinutes != 10+15+22 {
+		t.Errorf("want streams=3, billed=47; got %d, %d", r.Streams, r.BilledMinutes)
+	}
+	if r.CPUMinutes != 20+15+22 {
+		t.Errorf("want cpu minutes=57; got %v", r.CPUMinutes)
+	}
+	if r.PeakConcurrent != 3 || r.PeakAt == nil || !r.PeakAt.Equal(*at(8)) {
+		t.Errorf("want peak=3 at %v; got %d at %v", *at(8), r.PeakConcurrent, r.PeakAt)
+	}
+	expProfiles := []Usage{
+		{ID: "p1", Streams: 2, BilledMinutes: 32, CPUMinutes: 42},
+		{ID: "p2", Streams: 1, BilledMinutes: 15, CPUMinutes: 15},
+	}
+	if !reflect.DeepEqual(r.Profiles, expProfiles) {
+		t.Errorf("want profiles=%v; got %v", expProfiles, r.Profiles)
+	}
+	expAccounts := []Usage{
+		{ID: "a1", Streams: 2, BilledMinutes: 25, CPUMinutes: 35},
+		{ID: "a2", Streams: 1, BilledMinutes: 22, CPUMinutes: 22},
+	}
+	if !reflect.DeepEqual(r.Accounts, expAccounts) {
+		t.Errorf("want accounts=%v; got %v", expAccounts, r.Accounts)
+	}
+	buf := &bytes.Buffer{}
+	if err := r.WriteCSV(buf); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := "kind,id,streams,billed_minutes,cpu_minutes\n" +
+		"profile,p1,2,32,42.00\n" +
+		"profile,p2,1,15,15.00\n" +
+		"account,a1,2,25,35.00\n" +
+		"account,a2,1,22,22.00\n" +
+		"total,,3,47,57.00\n"
+	if buf.String() != exp {
+		t.Errorf("want csv=%q; got %q", exp, buf.String())
+	}
+}
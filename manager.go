@@ -1,10 +1,11 @@
 // Package panda provides a client for PandaStream service
 package panda
 
-//go:generate structgen -dir=json -tags=url -o=models.go -pkg=panda -types=created_at:Time,updated_at:Time,height:int,width:int,duration:int,file_size:int64,audio_bitrate:int,audio_channels:int,video_bitrate:int,audio_sample_rate:int,watermark_bottom:int,watermark_height:int,watermark_left:int,watermark_right:int,watermark_top:int,watermark_width:int,keyframe_interval:int,buffer_size:int,max_rate:int,frame_count:int,h264_crf:int,status:Status,page:int,per_page:int,aspect_mode:AspectMode
+//go:generate go run ./internal/cmd/genmodels -dir=json -config=internal/cmd/genmodels/config.json -o=models.go
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -62,20 +63,25 @@ type Manager struct {
 	Client *Client
 }
 
-func (m *Manager) manageGet(url string, v interface{}, params url.Values) (err error) {
-	b, err := m.Client.Get(url, params)
+// WithContext returns a manager whose calls carry the given context
+func (m *Manager) WithContext(ctx context.Context) *Manager {
+	return &Manager{Client: m.Client.WithContext(ctx)}
+}
+
+func (m *Manager) manageGet(op, url string, v interface{}, params url.Values) (err error) {
+	b, err := m.Client.do(op, "GET", url, "", params, nil)
 	if err == nil {
 		err = json.Unmarshal(b, v)
 	}
 	return
 }
 
-func (m *Manager) managePost(url string, r io.Reader, p, v interface{}) error {
+func (m *Manager) managePost(op, url string, r io.Reader, p, v interface{}) error {
 	params, err := query.Values(p)
 	if err != nil {
 		return err
 	}
-	b, err := m.Client.Post(url, "", params, r)
+	b, err := m.Client.do(op, "POST", url, "", params, r)
 	if err != nil {
 		return err
 	}
@@ -85,7 +91,7 @@ func (m *Manager) managePost(url string, r io.Reader, p, v interface{}) error {
 // Cloud gets cloud by the given cloud ID
 func (m *Manager) Cloud(id string) (*Cloud, error) {
 	c := new(Cloud)
-	if err := m.manageGet(fmt.Sprintf(cloudsIdPath, id), c, nil); err != nil {
+	if err := m.manageGet("Cloud", fmt.Sprintf(cloudsIdPath, id), c, nil); err != nil {
 		return nil, err
 	}
 	return c, nil
@@ -93,14 +99,14 @@ func (m *Manager) Cloud(id string) (*Cloud, error) {
 
 // Clouds gets all clouds on the given account
 func (m *Manager) Clouds() (cs []Cloud, err error) {
-	err = m.manageGet(cloudsPath, &cs, nil)
+	err = m.manageGet("Clouds", cloudsPath, &cs, nil)
 	return
 }
 
 // NewEncoding creates a new encoding for the existing video
 func (m *Manager) NewEncoding(er *NewEncodingRequest) (*Encoding, error) {
 	e := new(Encoding)
-	if err := m.managePost(encodingsPath, nil, er, &e); err != nil {
+	if err := m.managePost("NewEncoding", encodingsPath, nil, er, &e); err != nil {
 		return nil, err
 	}
 	return e, nil
@@ -109,7 +115,7 @@ func (m *Manager) NewEncoding(er *NewEncodingRequest) (*Encoding, error) {
 // Encoding gets encoding object with the given id
 func (m *Manager) Encoding(id string) (*Encoding, error) {
 	e := new(Encoding)
-	if err := m.manageGet(fmt.Sprintf(encodingsIdPath, id), e, nil); err != nil {
+	if err := m.manageGet("Encoding", fmt.Sprintf(encodingsIdPath, id), e, nil); err != nil {
 		return nil, err
 	}
 	return e, nil
@@ -125,19 +131,19 @@ func (m *Manager) Encodings(er *EncodingRequest) (es []Encoding, err error) {
 			return
 		}
 	}
-	err = m.manageGet(encodingsPath, &es, params)
+	err = m.manageGet("Encodings", encodingsPath, &es, params)
 	return
 }
 
 // Cancel encoding with the given id
 func (m *Manager) Cancel(id string) error {
-	_, err := m.Client.Post(fmt.Sprintf(encodingsIdCancelPath, id), "", nil, nil)
+	_, err := m.Client.do("Cancel", "POST", fmt.Sprintf(encodingsIdCancelPath, id), "", nil, nil)
 	return err
 }
 
 // Retry encoding with the given id
 func (m *Manager) Retry(id string) error {
-	_, err := m.Client.Post(fmt.Sprintf(encodingsIdRetryPath, id), "", nil, nil)
+	_, err := m.Client.do("Retry", "POST", fmt.Sprintf(encodingsIdRetryPath, id), "", nil, nil)
 	return err
 }
 
@@ -155,14 +161,23 @@ func (m *Manager) Delete(v interface{}) error {
 	default:
 		panic("Invalid type")
 	}
-	_, err := m.Client.Delete(path)
+	_, err := m.Client.do("Delete", "DELETE", path, "", nil, nil)
 	return err
 }
 
 // NewProfile creates new profile based on profile request object
 func (m *Manager) NewProfile(pr *NewProfileRequest) (*Profile, error) {
+	params, err := query.Values(pr)
+	if err != nil {
+		return nil, err
+	}
+	addExtra(params, pr.Extra)
+	b, err := m.Client.do("NewProfile", "POST", profilesPath, "", params, nil)
+	if err != nil {
+		return nil, err
+	}
 	p := new(Profile)
-	if err := m.managePost(profilesPath, nil, pr, p); err != nil {
+	if err = json.Unmarshal(b, p); err != nil {
 		return nil, err
 	}
 	return p, nil
@@ -171,7 +186,7 @@ func (m *Manager) NewProfile(pr *NewProfileRequest) (*Profile, error) {
 // Profile gets profile with the given ID
 func (m *Manager) Profile(id string) (*Profile, error) {
 	p := new(Profile)
-	if err := m.manageGet(fmt.Sprintf(profilesIdPath, id), p, nil); err != nil {
+	if err := m.manageGet("Profile", fmt.Sprintf(profilesIdPath, id), p, nil); err != nil {
 		return nil, err
 	}
 	return p, nil
@@ -186,12 +201,14 @@ func (m *Manager) Profiles(pr *ProfileRequest) (ps []Profile, err error) {
 			return
 		}
 	}
-	err = m.manageGet(profilesPath, &ps, params)
+	err = m.manageGet("Profiles", profilesPath, &ps, params)
 	return
 }
 
 // Update accepts *Profile and *Notification types and updates records based on the given objects.
-// Warning: the given parameter might change if any of the parameters are invalid
+// Warning: the given parameter might change if any of the parameters are invalid.
+// Use UpdateProfile and PatchNotifications to change single settings. Unknown fields
+// kept in Extra are sent along, so that they are not reset on the server.
 func (m *Manager) Update(v interface{}) error {
 	var path string
 	switch t := v.(type) {
@@ -206,7 +223,13 @@ func (m *Manager) Update(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	b, err := m.Client.Put(path, "", params, nil)
+	switch t := v.(type) {
+	case *Profile:
+		addExtra(params, t.Extra)
+	case *Notification:
+		addExtra(params, t.Extra)
+	}
+	b, err := m.Client.do("Update", "PUT", path, "", params, nil)
 	if err != nil {
 		return err
 	}
@@ -231,7 +254,7 @@ func (m *Manager) NewVideoURL(URL string, vr *NewVideoRequest) (*Video, error) {
 		return nil, err
 	}
 	params.Set("source_url", URL)
-	b, err := m.Client.Post(videosPath, "", params, nil)
+	b, err := m.Client.do("NewVideoURL", "POST", videosPath, "", params, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +288,7 @@ func (m *Manager) NewVideoReader(r io.Reader, name string, vr *NewVideoRequest)
 			return nil, err
 		}
 	}
-	b, err := m.Client.Post(videosPath, w.FormDataContentType(), params, buf)
+	b, err := m.Client.do("NewVideoReader", "POST", videosPath, w.FormDataContentType(), params, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +302,7 @@ func (m *Manager) NewVideoReader(r io.Reader, name string, vr *NewVideoRequest)
 // Video gets video with the given id
 func (m *Manager) Video(id string) (*Video, error) {
 	v := new(Video)
-	if err := m.manageGet(fmt.Sprintf(videosIdPath, id), &v, nil); err != nil {
+	if err := m.manageGet("Video", fmt.Sprintf(videosIdPath, id), &v, nil); err != nil {
 		return nil, err
 	}
 	return v, nil
@@ -295,7 +318,7 @@ func (m *Manager) Videos(vr *VideoRequest) (v []Video, err error) {
 			return nil, err
 		}
 	}
-	err = m.manageGet(videosPath, &v, params)
+	err = m.manageGet("Videos", videosPath, &v, params)
 	return
 }
 
@@ -310,14 +333,14 @@ func (m *Manager) VideoEncodings(id string, er *EncodingRequest) (es []Encoding,
 			return nil, err
 		}
 	}
-	err = m.manageGet(fmt.Sprintf(videosIdEncodingPath, id), &es, params)
+	err = m.manageGet("VideoEncodings", fmt.Sprintf(videosIdEncodingPath, id), &es, params)
 	return
 }
 
 // VideoMetaData gets meta data for the video with the given id
 func (m *Manager) VideoMetaData(id string) (MetaData, error) {
 	md := MetaData{}
-	if err := m.manageGet(fmt.Sprintf(videosIdMetaDataPath, id), &md, nil); err != nil {
+	if err := m.manageGet("VideoMetaData", fmt.Sprintf(videosIdMetaDataPath, id), &md, nil); err != nil {
 		return nil, err
 	}
 	return md, nil
@@ -325,15 +348,19 @@ func (m *Manager) VideoMetaData(id string) (MetaData, error) {
 
 // DeleteSource deletes the source video for the given video id
 func (m *Manager) DeleteSource(id string) error {
-	_, err := m.Client.Delete(fmt.Sprintf(videosIdDeleteSourcePath, id))
+	_, err := m.Client.do("DeleteSource", "DELETE", fmt.Sprintf(videosIdDeleteSourcePath, id), "", nil, nil)
 	return err
 }
 
-// Notifications gets notifications for the current cloud
+// Notifications gets notifications for the current cloud. A URL reported as
+// "null" by Panda is returned empty.
 func (m *Manager) Notifications() (*Notification, error) {
 	n := new(Notification)
-	if err := m.manageGet(notificationsPath, n, nil); err != nil {
+	if err := m.manageGet("Notifications", notificationsPath, n, nil); err != nil {
 		return nil, err
 	}
+	if n.URL == nullURL {
+		n.URL = ""
+	}
 	return n, nil
 }
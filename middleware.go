@@ -0,0 +1,57 @@
+package panda
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Call describes a single request to the Panda Cloud before it gets signed
+type Call struct {
+	// Operation names the library method which issued the call, e.g. "NewEncoding"
+	// or "live.StreamCreate". It is empty for calls made directly with Get, Post,
+	// Put and Delete.
+	Operation string
+	Method    string
+	Path      string
+	// Template is the path with all IDs replaced by {id}, e.g. /videos/{id}.json
+	Template    string
+	ContentType string
+	// Params holds the query parameters without any of the authorization fields
+	Params url.Values
+	Body   io.Reader
+	// Retries counts how many times the call has been retried by middleware
+	Retries int
+	// Context carries deadlines and trace information of the caller, it is the
+	// client's context as set by WithContext if nil
+	Context context.Context
+}
+
+// Response is the raw response to a Call
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// CallHandler handles a call and returns its response. Responses with error
+// status codes are returned with a nil error.
+type CallHandler func(*Call) (*Response, error)
+
+// Middleware wraps a CallHandler. It can inspect or modify the call before passing
+// it on to next, inspect or modify the response, or return a response of its
+// own without calling next at all.
+type Middleware func(next CallHandler) CallHandler
+
+// Use appends the given middleware to the client's middleware chain
+func (cl *Client) Use(mw ...Middleware) {
+	cl.Middleware = append(cl.Middleware, mw...)
+}
+
+func (cl *Client) chain(h CallHandler) CallHandler {
+	for i := len(cl.Middleware) - 1; i >= 0; i-- {
+		h = cl.Middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,76 @@
+package panda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var gotProfile string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotProfile = r.URL.Query().Get("profile_name")
+		mustWrite(w, []byte(`{"id":"e1"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	var trace []string
+	m.Client.Use(
+		func(next CallHandler) CallHandler {
+			return func(c *Call) (*Response, error) {
+				trace = append(trace, "outer:"+c.Operation)
+				if c.Params.Get("signature") != "" || c.Params.Get("access_key") != "" {
+					t.Error("want params to be unsigned")
+				}
+				c.Params.Set("profile_name", "h264")
+				resp, err := next(c)
+				trace = append(trace, "outer:done")
+				return resp, err
+			}
+		},
+		func(next CallHandler) CallHandler {
+			return func(c *Call) (*Response, error) {
+				trace = append(trace, "inner:"+c.Method+" "+c.Path)
+				return next(c)
+			}
+		},
+	)
+	e, err := m.NewEncoding(&NewEncodingRequest{VideoID: "v1"})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if e.ID != "e1" {
+		t.Errorf("want id=e1; got %s", e.ID)
+	}
+	if gotProfile != "h264" {
+		t.Errorf("want profile_name=h264; got %q", gotProfile)
+	}
+	exp := []string{"outer:NewEncoding", "inner:POST /encodings.json", "outer:done"}
+	if !reflect.DeepEqual(trace, exp) {
+		t.Errorf("want trace=%v; got %v", exp, trace)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	m := newManager("http://127.0.0.1:1", t)
+	m.Client.Use(func(next CallHandler) CallHandler {
+		return func(c *Call) (*Response, error) {
+			if c.Operation == "Cancel" {
+				return &Response{StatusCode: http.StatusNotFound, Body: []byte(`{"error":"NotFound"}`)}, nil
+			}
+			return &Response{StatusCode: http.StatusOK, Body: []byte(`{"name":"cached"}`)}, nil
+		}
+	})
+	p, err := m.Profile("p1")
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if p.Name != "cached" {
+		t.Errorf("want name=cached; got %s", p.Name)
+	}
+	exp := &Error{Code: http.StatusNotFound, Err: "NotFound"}
+	if err := m.Cancel("e1"); !reflect.DeepEqual(err, exp) {
+		t.Errorf("want err=%v; got %v", exp, err)
+	}
+}
@@ -11,6 +11,19 @@ type Cloud struct {
 	S3VideosBucket  string `json:"s3_videos_bucket,omitempty" url:"s3_videos_bucket,omitempty"`
 	URL             string `json:"url,omitempty" url:"url,omitempty"`
 	UpdatedAt       Time   `json:"updated_at,omitempty" url:"updated_at,omitempty"`
+	Extra           Extra  `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Cloud) UnmarshalJSON(b []byte) error {
+	type model Cloud
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Cloud) MarshalJSON() ([]byte, error) {
+	type model Cloud
+	return marshalExtra(model(v), v.Extra)
 }
 
 // Encoding was autogenerated by go generate.
@@ -43,6 +56,19 @@ type Encoding struct {
 	VideoCodec        string   `json:"video_codec,omitempty" url:"video_codec,omitempty"`
 	VideoID           string   `json:"video_id,omitempty" url:"video_id,omitempty"`
 	Width             int      `json:"width,omitempty" url:"width,omitempty"`
+	Extra             Extra    `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Encoding) UnmarshalJSON(b []byte) error {
+	type model Encoding
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Encoding) MarshalJSON() ([]byte, error) {
+	type model Encoding
+	return marshalExtra(model(v), v.Extra)
 }
 
 // EncodingRequest was autogenerated by go generate.
@@ -57,10 +83,23 @@ type EncodingRequest struct {
 
 // Events was autogenerated by go generate.
 type Events struct {
-	EncodingCompleted bool `json:"encoding_completed,omitempty" url:"encoding_completed,omitempty"`
-	EncodingProgress  bool `json:"encoding_progress,omitempty" url:"encoding_progress,omitempty"`
-	VideoCreated      bool `json:"video_created,omitempty" url:"video_created,omitempty"`
-	VideoEncoded      bool `json:"video_encoded,omitempty" url:"video_encoded,omitempty"`
+	EncodingCompleted bool  `json:"encoding_completed,omitempty" url:"encoding_completed,omitempty"`
+	EncodingProgress  bool  `json:"encoding_progress,omitempty" url:"encoding_progress,omitempty"`
+	VideoCreated      bool  `json:"video_created,omitempty" url:"video_created,omitempty"`
+	VideoEncoded      bool  `json:"video_encoded,omitempty" url:"video_encoded,omitempty"`
+	Extra             Extra `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Events) UnmarshalJSON(b []byte) error {
+	type model Events
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Events) MarshalJSON() ([]byte, error) {
+	type model Events
+	return marshalExtra(model(v), v.Extra)
 }
 
 // NewEncodingRequest was autogenerated by go generate.
@@ -114,6 +153,19 @@ type NewProfileRequest struct {
 	WatermarkURL     string     `json:"watermark_url,omitempty" url:"watermark_url,omitempty"`
 	WatermarkWidth   int        `json:"watermark_width,omitempty" url:"watermark_width,omitempty"`
 	Width            int        `json:"width,omitempty" url:"width,omitempty"`
+	Extra            Extra      `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *NewProfileRequest) UnmarshalJSON(b []byte) error {
+	type model NewProfileRequest
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v NewProfileRequest) MarshalJSON() ([]byte, error) {
+	type model NewProfileRequest
+	return marshalExtra(model(v), v.Extra)
 }
 
 // NewVideoRequest was autogenerated by go generate.
@@ -128,6 +180,19 @@ type Notification struct {
 	Delay  float64 `json:"delay,omitempty" url:"delay,omitempty"`
 	Events Events  `json:"events,omitempty" url:"events,omitempty"`
 	URL    string  `json:"url,omitempty" url:"url,omitempty"`
+	Extra  Extra   `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Notification) UnmarshalJSON(b []byte) error {
+	type model Notification
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Notification) MarshalJSON() ([]byte, error) {
+	type model Notification
+	return marshalExtra(model(v), v.Extra)
 }
 
 // Profile was autogenerated by go generate.
@@ -177,6 +242,19 @@ type Profile struct {
 	WatermarkURL     string     `json:"watermark_url,omitempty" url:"watermark_url,omitempty"`
 	WatermarkWidth   int        `json:"watermark_width,omitempty" url:"watermark_width,omitempty"`
 	Width            int        `json:"width,omitempty" url:"width,omitempty"`
+	Extra            Extra      `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Profile) UnmarshalJSON(b []byte) error {
+	type model Profile
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Profile) MarshalJSON() ([]byte, error) {
+	type model Profile
+	return marshalExtra(model(v), v.Extra)
 }
 
 // ProfileRequest was autogenerated by go generate.
@@ -211,6 +289,19 @@ type Video struct {
 	VideoBitrate     int     `json:"video_bitrate,omitempty" url:"video_bitrate,omitempty"`
 	VideoCodec       string  `json:"video_codec,omitempty" url:"video_codec,omitempty"`
 	Width            int     `json:"width,omitempty" url:"width,omitempty"`
+	Extra            Extra   `json:"-" url:"-"`
+}
+
+// UnmarshalJSON was autogenerated by go generate.
+func (v *Video) UnmarshalJSON(b []byte) error {
+	type model Video
+	return unmarshalExtra(b, (*model)(v), &v.Extra)
+}
+
+// MarshalJSON was autogenerated by go generate.
+func (v Video) MarshalJSON() ([]byte, error) {
+	type model Video
+	return marshalExtra(model(v), v.Extra)
 }
 
 // VideoRequest was autogenerated by go generate.
@@ -0,0 +1,214 @@
+package panda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// nullURL is how Panda reports a notification URL that was never set
+const nullURL = "null"
+
+// NotificationPatch describes a change of notification settings. Nil fields and
+// event types missing from Events are left as they are.
+type NotificationPatch struct {
+	// URL changes the notification URL, an empty string removes it
+	URL    *string
+	Delay  *float64
+	Events map[EventType]bool
+}
+
+// SetURL sets the notification URL to change to
+func (p *NotificationPatch) SetURL(u string) *NotificationPatch {
+	p.URL = &u
+	return p
+}
+
+// SetDelay sets the notification delay to change to
+func (p *NotificationPatch) SetDelay(d float64) *NotificationPatch {
+	p.Delay = &d
+	return p
+}
+
+// Enable turns notifications for the given event types on
+func (p *NotificationPatch) Enable(ts ...EventType) *NotificationPatch {
+	return p.set(true, ts)
+}
+
+// Disable turns notifications for the given event types off
+func (p *NotificationPatch) Disable(ts ...EventType) *NotificationPatch {
+	return p.set(false, ts)
+}
+
+func (p *NotificationPatch) set(on bool, ts []EventType) *NotificationPatch {
+	if p.Events == nil {
+		p.Events = make(map[EventType]bool)
+	}
+	for _, t := range ts {
+		p.Events[t] = on
+	}
+	return p
+}
+
+// Apply returns a copy of n with the patch applied
+func (p *NotificationPatch) Apply(n *Notification) (*Notification, error) {
+	m := *n
+	if m.URL == nullURL {
+		m.URL = ""
+	}
+	if p.URL != nil {
+		m.URL = *p.URL
+	}
+	if p.Delay != nil {
+		m.Delay = *p.Delay
+	}
+	for t, on := range p.Events {
+		f := m.Events.flag(t)
+		if f == nil {
+			return nil, fmt.Errorf("panda: unknown notification event %q", t)
+		}
+		*f = on
+	}
+	return &m, nil
+}
+
+func (e *Events) flag(t EventType) *bool {
+	switch t {
+	case EventVideoCreated:
+		return &e.VideoCreated
+	case EventVideoEncoded:
+		return &e.VideoEncoded
+	case EventEncodingProgress:
+		return &e.EncodingProgress
+	case EventEncodingCompleted:
+		return &e.EncodingCompleted
+	}
+	return nil
+}
+
+// HasURL reports whether a notification URL is set
+func (n *Notification) HasURL() bool {
+	return n.URL != "" && n.URL != nullURL
+}
+
+// Validate checks that the notification URL, if set, is an absolute https URL
+// and that the delay is not negative
+func (n *Notification) Validate() error {
+	if n.Delay < 0 {
+		return errors.New("panda: notification delay must not be negative")
+	}
+	if !n.HasURL() {
+		return nil
+	}
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return fmt.Errorf("panda: invalid notification URL: %v", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("panda: notification URL %q must be an absolute https URL", n.URL)
+	}
+	return nil
+}
+
+// NotificationChange is a single setting changed by a NotificationPatch
+type NotificationChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c NotificationChange) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// DiffNotifications lists the settings which differ between a and b. Unset and
+// "null" URLs are considered equal.
+func DiffNotifications(a, b *Notification) []NotificationChange {
+	av, bv := notificationValues(a), notificationValues(b)
+	var cs []NotificationChange
+	for k := range av {
+		if av.Get(k) != bv.Get(k) {
+			cs = append(cs, NotificationChange{Field: k, Old: av.Get(k), New: bv.Get(k)})
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Field < cs[j].Field })
+	return cs
+}
+
+// notificationValues encodes all settings, including disabled events and an
+// empty URL, so that an update never leaves values on the server untouched
+func notificationValues(n *Notification) url.Values {
+	u := n.URL
+	if u == nullURL {
+		u = ""
+	}
+	v := url.Values{
+		"url":                        {u},
+		"delay":                      {strconv.FormatFloat(n.Delay, 'f', -1, 64)},
+		"events[video_created]":      {strconv.FormatBool(n.Events.VideoCreated)},
+		"events[video_encoded]":      {strconv.FormatBool(n.Events.VideoEncoded)},
+		"events[encoding_progress]":  {strconv.FormatBool(n.Events.EncodingProgress)},
+		"events[encoding_completed]": {strconv.FormatBool(n.Events.EncodingCompleted)},
+	}
+	for k, ev := range n.Events.Extra.Values() {
+		if _, ok := v["events["+k+"]"]; !ok {
+			v["events["+k+"]"] = ev
+		}
+	}
+	addExtra(v, n.Extra)
+	return v
+}
+
+// PreviewNotifications fetches the current notification settings and returns the
+// changes the patch would make without applying them
+func (m *Manager) PreviewNotifications(p *NotificationPatch) ([]NotificationChange, error) {
+	cur, err := m.Notifications()
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.Apply(cur)
+	if err != nil {
+		return nil, err
+	}
+	if err = n.Validate(); err != nil {
+		return nil, err
+	}
+	return DiffNotifications(cur, n), nil
+}
+
+// PatchNotifications fetches the current notification settings, applies the patch
+// and updates them, leaving settings not mentioned by the patch unchanged. It
+// returns the updated settings and the changes made. Nothing is sent if the patch
+// changes nothing.
+func (m *Manager) PatchNotifications(p *NotificationPatch) (*Notification, []NotificationChange, error) {
+	cur, err := m.Notifications()
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := p.Apply(cur)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = n.Validate(); err != nil {
+		return nil, nil, err
+	}
+	cs := DiffNotifications(cur, n)
+	if len(cs) == 0 {
+		return n, nil, nil
+	}
+	b, err := m.Client.do("PatchNotifications", "PUT", notificationsPath, "", notificationValues(n), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	upd := new(Notification)
+	if err = json.Unmarshal(b, upd); err != nil {
+		return nil, nil, err
+	}
+	if upd.URL == nullURL {
+		upd.URL = ""
+	}
+	return upd, cs, nil
+}
@@ -0,0 +1,117 @@
+package panda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestNotificationValidate(t *testing.T) {
+	cases := []struct {
+		n     Notification
+		valid bool
+	}{
+		{Notification{}, true},
+		{Notification{URL: "null"}, true},
+		{Notification{URL: "https://example.com/panda"}, true},
+		{Notification{URL: "http://example.com/panda"}, false},
+		{Notification{URL: "/panda"}, false},
+		{Notification{URL: "https:///panda"}, false},
+		{Notification{URL: "https://example.com", Delay: -1}, false},
+	}
+	for i, c := range cases {
+		if err := c.n.Validate(); (err == nil) != c.valid {
+			t.Errorf("want valid=%t; got err=%v (i=%d)", c.valid, err, i)
+		}
+	}
+}
+
+func TestNotificationPatchApply(t *testing.T) {
+	n := &Notification{URL: "null", Delay: 10, Events: Events{VideoCreated: true, EncodingProgress: true}}
+	p := new(NotificationPatch).Enable(EventEncodingCompleted).Disable(EventEncodingProgress).SetDelay(0)
+	got, err := p.Apply(n)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := &Notification{Events: Events{VideoCreated: true, EncodingCompleted: true}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("want %+v; got %+v", exp, got)
+	}
+	if n.Delay != 10 {
+		t.Error("want Apply not to modify the original")
+	}
+	if _, err := new(NotificationPatch).Enable("video-deleted").Apply(n); err == nil {
+		t.Error("want error for unknown event")
+	}
+	cs := DiffNotifications(n, got)
+	expcs := []NotificationChange{
+		{"delay", "10", "0"},
+		{"events[encoding_completed]", "false", "true"},
+		{"events[encoding_progress]", "true", "false"},
+	}
+	if !reflect.DeepEqual(cs, expcs) {
+		t.Errorf("want changes=%v; got %v", expcs, cs)
+	}
+}
+
+func TestPatchNotifications(t *testing.T) {
+	b, err := ioutil.ReadFile("json/notification.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var put url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			put = r.URL.Query()
+			n := &Notification{URL: put.Get("url"), Delay: 10, Events: Events{VideoEncoded: true}}
+			b, err := json.Marshal(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mustWrite(w, b)
+			return
+		}
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+
+	cs, err := m.PreviewNotifications(new(NotificationPatch).SetURL("https://example.com/panda"))
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if exp := []NotificationChange{{"url", "", "https://example.com/panda"}}; !reflect.DeepEqual(cs, exp) {
+		t.Errorf("want changes=%v; got %v", exp, cs)
+	}
+	if put != nil {
+		t.Error("want preview not to update notifications")
+	}
+
+	if _, _, err = m.PatchNotifications(new(NotificationPatch).SetURL("http://example.com")); err == nil {
+		t.Error("want error for http URL")
+	}
+	n, cs, err := m.PatchNotifications(new(NotificationPatch).Enable(EventVideoEncoded))
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(cs) != 1 || !n.Events.VideoEncoded {
+		t.Errorf("want video_encoded to be enabled; got %+v (changes=%v)", n, cs)
+	}
+	exp := url.Values{
+		"url":                        {""},
+		"delay":                      {"10"},
+		"events[video_created]":      {"false"},
+		"events[video_encoded]":      {"true"},
+		"events[encoding_progress]":  {"false"},
+		"events[encoding_completed]": {"false"},
+	}
+	for k := range exp {
+		if put.Get(k) != exp.Get(k) {
+			t.Errorf("want %s=%q; got %q", k, exp.Get(k), put.Get(k))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package otelpanda instruments panda.Client calls with OpenTelemetry traces and metrics.
+//
+// Every call gets a client span named after its operation and path template, a child
+// of the span in the call's context (see panda.Client.WithContext). Call
+// parameters are never recorded, so access keys, signatures, tokens and other
+// secrets do not end up in the collected telemetry.
+package otelpanda
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pandastream/go-panda"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/pandastream/go-panda/otelpanda"
+
+// Attribute keys recorded on spans and metrics
+const (
+	OperationKey  = "panda.operation"
+	RetriesKey    = "panda.retries"
+	MethodKey     = "http.request.method"
+	TemplateKey   = "url.template"
+	StatusCodeKey = "http.response.status_code"
+	BodySizeKey   = "http.request.body.size"
+)
+
+// Options configure the instrumentation. Global providers are used for nil fields.
+type Options struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+// Instrument adds the tracing and metrics middleware to the given client
+func Instrument(cl *panda.Client, opts *Options) error {
+	mw, err := Middleware(opts)
+	if err != nil {
+		return err
+	}
+	cl.Use(mw)
+	return nil
+}
+
+// Middleware returns a panda.Middleware which creates a span for each call and records
+// the panda.client.duration histogram and the panda.client.errors counter. It should
+// be the outermost middleware so that retries done by other middleware are
+// accounted for within a single span.
+func Middleware(opts *Options) (panda.Middleware, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	tp, mp := opts.TracerProvider, opts.MeterProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	tracer := tp.Tracer(instrumentationName)
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("panda.client.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of calls to the Panda Cloud"))
+	if err != nil {
+		return nil, err
+	}
+	failures, err := meter.Int64Counter("panda.client.errors",
+		metric.WithDescription("Number of failed calls to the Panda Cloud"))
+	if err != nil {
+		return nil, err
+	}
+	return func(next panda.CallHandler) panda.CallHandler {
+		return func(c *panda.Call) (*panda.Response, error) {
+			parent := c.Context
+			if parent == nil {
+				parent = context.Background()
+			}
+			ctx, span := tracer.Start(parent, spanName(c),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String(OperationKey, c.Operation),
+					attribute.String(MethodKey, c.Method),
+					attribute.String(TemplateKey, c.Template),
+				))
+			defer span.End()
+			c.Context = ctx
+			body := &countingReader{r: c.Body}
+			if c.Body != nil {
+				c.Body = body
+			}
+			start := time.Now()
+			resp, err := next(c)
+			elapsed := time.Since(start)
+
+			attrs := []attribute.KeyValue{
+				attribute.String(OperationKey, c.Operation),
+				attribute.String(MethodKey, c.Method),
+				attribute.String(TemplateKey, c.Template),
+			}
+			if resp != nil {
+				attrs = append(attrs, attribute.Int(StatusCodeKey, resp.StatusCode))
+			}
+			span.SetAttributes(attrs...)
+			span.SetAttributes(
+				attribute.Int(RetriesKey, c.Retries),
+				attribute.Int64(BodySizeKey, body.n),
+			)
+			duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+			switch {
+			case err != nil:
+				rerr := redact(err)
+				span.RecordError(rerr)
+				span.SetStatus(codes.Error, rerr.Error())
+				failures.Add(ctx, 1, metric.WithAttributes(attrs...))
+			case resp.StatusCode >= 400:
+				span.SetStatus(codes.Error, "HTTP "+strconv.Itoa(resp.StatusCode))
+				failures.Add(ctx, 1, metric.WithAttributes(attrs...))
+			}
+			return resp, err
+		}
+	}, nil
+}
+
+func spanName(c *panda.Call) string {
+	if c.Operation != "" {
+		return "panda." + c.Operation
+	}
+	return c.Method + " " + c.Template
+}
+
+// redact strips the query, which holds the access key and signature, from the
+// URL of transport errors
+func redact(err error) error {
+	ue, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u := ue.URL
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		u = u[:i]
+	}
+	return &url.Error{Op: ue.Op, URL: u, Err: ue.Err}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,172 @@
+package otelpanda
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pandastream/go-panda"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	accessKey = "AKIDACCESSKEY"
+	secretKey = "SECRETKEY"
+)
+
+func newClient(addr string, t *testing.T) *panda.Client {
+	u, err := url.Parse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &panda.Client{
+		Host: u.Host,
+		Options: &panda.ClientOptions{
+			CloudID:   "cloud",
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+		},
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/videos/v1.json" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"RecordNotFound","message":"no video"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"v1"}`))
+	}))
+	defer ts.Close()
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cl := newClient(ts.URL, t)
+	if err := Instrument(cl, &Options{TracerProvider: tp, MeterProvider: mp}); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	m := (&panda.Manager{Client: cl}).WithContext(ctx)
+	if _, err := m.Video("v1"); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if _, err := m.Video("missing"); err == nil {
+		t.Fatal("want err!=nil")
+	}
+	parent.End()
+	ts.Close()
+	if _, err := m.Video("v1"); err == nil {
+		t.Fatal("want err!=nil for closed server")
+	}
+
+	got := spans.GetSpans()
+	if len(got) != 4 {
+		t.Fatalf("want 4 spans; got %d", len(got))
+	}
+	cases := []struct {
+		status codes.Code
+		code   int64
+		events int
+	}{
+		{codes.Unset, 200, 0},
+		{codes.Error, 404, 0},
+		{codes.Error, 0, 1},
+	}
+	var calls []sdktrace.ReadOnlySpan
+	for _, s := range got.Snapshots() {
+		if s.Name() != "parent" {
+			calls = append(calls, s)
+		}
+	}
+	for i, c := range cases {
+		s := calls[i]
+		if s.Name() != "panda.Video" {
+			t.Errorf("want name=panda.Video; got %s (i=%d)", s.Name(), i)
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() || s.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+			t.Errorf("want span to be a child of the caller's span (i=%d)", i)
+		}
+		if s.Status().Code != c.status {
+			t.Errorf("want status=%v; got %v (i=%d)", c.status, s.Status().Code, i)
+		}
+		if len(s.Events()) != c.events {
+			t.Errorf("want %d events; got %d (i=%d)", c.events, len(s.Events()), i)
+		}
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		exp := map[attribute.Key]string{
+			OperationKey: "Video",
+			MethodKey:    "GET",
+			TemplateKey:  "/videos/{id}.json",
+		}
+		for k, v := range exp {
+			if attrs[k].AsString() != v {
+				t.Errorf("want %s=%s; got %s (i=%d)", k, v, attrs[k].AsString(), i)
+			}
+		}
+		if code, ok := attrs[StatusCodeKey]; c.code != 0 && (!ok || code.AsInt64() != c.code) {
+			t.Errorf("want status code %d; got %v (i=%d)", c.code, code.AsInt64(), i)
+		}
+		assertNoSecrets(t, s.Status().Description)
+		for _, kv := range s.Attributes() {
+			assertNoSecrets(t, string(kv.Key)+"="+kv.Value.Emit())
+		}
+		for _, e := range s.Events() {
+			for _, kv := range e.Attributes {
+				assertNoSecrets(t, string(kv.Key)+"="+kv.Value.Emit())
+			}
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var calls64, errors64 int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				if m.Name == "panda.client.duration" {
+					for _, dp := range data.DataPoints {
+						calls64 += int64(dp.Count)
+						for _, kv := range dp.Attributes.ToSlice() {
+							assertNoSecrets(t, string(kv.Key)+"="+kv.Value.Emit())
+						}
+					}
+				}
+			case metricdata.Sum[int64]:
+				if m.Name == "panda.client.errors" {
+					for _, dp := range data.DataPoints {
+						errors64 += dp.Value
+					}
+				}
+			}
+		}
+	}
+	if calls64 != 3 || errors64 != 2 {
+		t.Errorf("want 3 recorded durations and 2 errors; got %d and %d", calls64, errors64)
+	}
+}
+
+func assertNoSecrets(t *testing.T, s string) {
+	t.Helper()
+	for _, secret := range []string{"signature", "access_key", accessKey, secretKey} {
+		if strings.Contains(s, secret) {
+			t.Errorf("want %q not to be recorded; got %s", secret, s)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package panda
 
+import "strings"
+
 const videosPath = "/videos.json"
 const videosIdPath = "/videos/%s.json"
 const videosIdEncodingPath = "/videos/%s/encodings.json"
@@ -21,3 +23,52 @@ const cloudsIdPath = "/clouds/%s.json"
 
 const notificationsPath = "/notifications.json"
 const notificationsIdPath = "/notifications/%s.json"
+
+// pathFormats lists all path formats used by the Manager. More specific formats
+// come first so that e.g. videosUploadPath is not mistaken for videosIdPath.
+var pathFormats = []string{
+	videosPath,
+	videosUploadPath,
+	videosIdEncodingPath,
+	videosIdMetaDataPath,
+	videosIdDeleteSourcePath,
+	videosIdPath,
+	encodingsPath,
+	encodingsIdCancelPath,
+	encodingsIdRetryPath,
+	encodingsIdPath,
+	profilesPath,
+	profilesIdPath,
+	cloudsPath,
+	cloudsIdPath,
+	notificationsPath,
+	notificationsIdPath,
+}
+
+// PathTemplate returns the first of the given formats which matches path with
+// every %s verb replaced by {id}, e.g. /videos/{id}.json for /videos/123.json.
+// If none of the formats match, path is returned unchanged.
+func PathTemplate(path string, formats ...string) string {
+	for _, f := range formats {
+		if matchFormat(path, f) {
+			return strings.Replace(f, "%s", "{id}", -1)
+		}
+	}
+	return path
+}
+
+func matchFormat(path, format string) bool {
+	parts := strings.Split(format, "%s")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	for _, p := range parts[1:] {
+		i := strings.Index(path, p)
+		if i <= 0 || strings.Contains(path[:i], "/") {
+			return false
+		}
+		path = path[i+len(p):]
+	}
+	return path == ""
+}
@@ -0,0 +1,252 @@
+package panda
+
+import (
+	"context"
+	"time"
+)
+
+// Poller synthesizes the notifications Panda would send by polling the videos
+// and encodings of the cloud, and delivers them to a WebhookHandler. It allows
+// developing against notifications without a public URL, with the application
+// code path identical to the one used with a webhook in production.
+//
+// The listings are polled from the most recent page on, until all videos and
+// encodings known to be processing have been seen again and a page held a known
+// item, so that neither changes of older items nor bursts of new ones are missed.
+// Processing items which are no longer listed are forgotten. Items which exist
+// when polling starts produce events only when they change afterwards, and only
+// those on the first page are tracked. If a handler function returns an error the
+// change is delivered again on the next poll.
+type Poller struct {
+	Manager *Manager
+	Handler *WebhookHandler
+	// Interval between polls, defaults to 5 seconds
+	Interval time.Duration
+	// PerPage is the page size of the polled listings, defaults to 100
+	PerPage int
+	// OnError is called with errors of polls and handler functions, if set
+	OnError func(error)
+
+	started   bool
+	videos    map[string]Status
+	encodings map[string]encodingState
+}
+
+type encodingState struct {
+	status   Status
+	progress int
+}
+
+func (p *Poller) interval() time.Duration {
+	if p.Interval > 0 {
+		return p.Interval
+	}
+	return 5 * time.Second
+}
+
+func (p *Poller) perPage() int {
+	if p.PerPage > 0 {
+		return p.PerPage
+	}
+	return defaultPerPage
+}
+
+// Run polls until ctx is done and returns ctx.Err()
+func (p *Poller) Run(ctx context.Context) error {
+	t := time.NewTicker(p.interval())
+	defer t.Stop()
+	for {
+		if err := p.Poll(); err != nil && p.OnError != nil {
+			p.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Poll fetches videos and encodings once and delivers events for all changes
+// since the previous poll. The first poll only records the current state.
+func (p *Poller) Poll() error {
+	if !p.started {
+		return p.start()
+	}
+	tracked := make(map[string]bool, len(p.videos))
+	for id, st := range p.videos {
+		tracked[id] = st == StatusProcessing
+	}
+	vs, err := pollPages(func(page int) ([]Video, error) {
+		return p.Manager.Videos(&VideoRequest{Page: page, PerPage: p.perPage()})
+	}, func(v *Video) string { return v.ID }, tracked)
+	if err != nil {
+		return err
+	}
+	tracked = make(map[string]bool, len(p.encodings))
+	for id, st := range p.encodings {
+		tracked[id] = st.status == StatusProcessing
+	}
+	es, err := pollPages(func(page int) ([]Encoding, error) {
+		return p.Manager.Encodings(&EncodingRequest{Page: page, PerPage: p.perPage()})
+	}, func(e *Encoding) string { return e.ID }, tracked)
+	if err != nil {
+		return err
+	}
+	p.forget(vs, es)
+	now := time.Now().UTC()
+
+	byVideo := make(map[string]map[string]string)
+	for _, e := range es {
+		if byVideo[e.VideoID] == nil {
+			byVideo[e.VideoID] = make(map[string]string)
+		}
+		byVideo[e.VideoID][e.ProfileID] = e.ID
+	}
+	for _, v := range vs {
+		old, ok := p.videos[v.ID]
+		if !ok {
+			ev := &Event{Type: EventVideoCreated, VideoID: v.ID, Timestamp: timestamp(v.CreatedAt), Received: now}
+			if !p.deliver(ev) {
+				continue
+			}
+			old = StatusProcessing
+			p.videos[v.ID] = old
+		}
+		if old == StatusProcessing && v.Status != StatusProcessing {
+			ev := &Event{Type: EventVideoEncoded, VideoID: v.ID, EncodingIDs: byVideo[v.ID],
+				Timestamp: timestamp(v.UpdatedAt), Received: now}
+			if !p.deliver(ev) {
+				continue
+			}
+		}
+		p.videos[v.ID] = v.Status
+	}
+	for _, e := range es {
+		old, ok := p.encodings[e.ID]
+		if !ok {
+			old = encodingState{status: StatusProcessing, progress: -1}
+		}
+		cur := encodingState{e.Status, int(e.EncodingProgress)}
+		if cur.status == StatusProcessing && cur.progress != old.progress {
+			ev := &Event{Type: EventEncodingProgress, VideoID: e.VideoID, EncodingID: e.ID,
+				Progress: cur.progress, Timestamp: timestamp(e.UpdatedAt), Received: now}
+			if !p.deliver(ev) {
+				continue
+			}
+			old.progress = cur.progress
+			p.encodings[e.ID] = old
+		}
+		if old.status == StatusProcessing && cur.status != StatusProcessing {
+			ev := &Event{Type: EventEncodingCompleted, VideoID: e.VideoID, EncodingID: e.ID,
+				Timestamp: timestamp(e.UpdatedAt), Received: now}
+			if !p.deliver(ev) {
+				continue
+			}
+		}
+		p.encodings[e.ID] = cur
+	}
+	return nil
+}
+
+// start records the state of the first page of videos and encodings
+func (p *Poller) start() error {
+	vs, err := p.Manager.Videos(&VideoRequest{PerPage: p.perPage()})
+	if err != nil {
+		return err
+	}
+	es, err := p.Manager.Encodings(&EncodingRequest{PerPage: p.perPage()})
+	if err != nil {
+		return err
+	}
+	p.videos = make(map[string]Status, len(vs))
+	p.encodings = make(map[string]encodingState, len(es))
+	for _, v := range vs {
+		p.videos[v.ID] = v.Status
+	}
+	for _, e := range es {
+		p.encodings[e.ID] = encodingState{e.Status, int(e.EncodingProgress)}
+	}
+	p.started = true
+	return nil
+}
+
+// forget stops tracking processing videos and encodings which were not listed,
+// they have been deleted
+func (p *Poller) forget(vs []Video, es []Encoding) {
+	listed := make(map[string]bool, len(vs)+len(es))
+	for _, v := range vs {
+		listed[v.ID] = true
+	}
+	for _, e := range es {
+		listed[e.ID] = true
+	}
+	for id, st := range p.videos {
+		if st == StatusProcessing && !listed[id] {
+			delete(p.videos, id)
+		}
+	}
+	for id, st := range p.encodings {
+		if st.status == StatusProcessing && !listed[id] {
+			delete(p.encodings, id)
+		}
+	}
+}
+
+// pollPages fetches the pages of a listing, most recent first, until all ids
+// tracked as pending have been listed and a page held a tracked item, or the
+// listing ends. tracked maps the ids of known items to whether they are pending.
+func pollPages[T any](fetch func(page int) ([]T, error), id func(*T) string,
+	tracked map[string]bool) ([]T, error) {
+	pending := 0
+	for _, p := range tracked {
+		if p {
+			pending++
+		}
+	}
+	var all []T
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		items, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+		n, known := len(all), false
+		for i := range items {
+			k := id(&items[i])
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			all = append(all, items[i])
+			if p, ok := tracked[k]; ok {
+				known = true
+				if p {
+					pending--
+				}
+			}
+		}
+		if len(all) == n || known && pending == 0 {
+			return all, nil
+		}
+	}
+}
+
+func (p *Poller) deliver(e *Event) bool {
+	if err := p.Handler.Dispatch(e); err != nil {
+		if p.OnError != nil {
+			p.OnError(err)
+		}
+		return false
+	}
+	return true
+}
+
+// timestamp returns t as an event timestamp, nil if t is zero
+func timestamp(t Time) *time.Time {
+	if time.Time(t).IsZero() {
+		return nil
+	}
+	ts := time.Time(t)
+	return &ts
+}
@@ -0,0 +1,188 @@
+package panda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPoller(t *testing.T) {
+	var mu sync.Mutex
+	var vs []Video
+	var es []Encoding
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var b []byte
+		var err error
+		switch r.URL.Path {
+		case "/v2/videos.json":
+			b, err = json.Marshal(vs)
+		case "/v2/encodings.json":
+			b, err = json.Marshal(es)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+
+	var got []string
+	failCompleted := true
+	p := &Poller{
+		Manager: newManager(ts.URL, t),
+		Handler: &WebhookHandler{
+			VideoCreated: func(e *Event) error {
+				got = append(got, fmt.Sprintf("%s %s", e.Type, e.VideoID))
+				return nil
+			},
+			VideoEncoded: func(e *Event) error {
+				got = append(got, fmt.Sprintf("%s %s %v", e.Type, e.VideoID, e.EncodingIDs))
+				return nil
+			},
+			EncodingProgress: func(e *Event) error {
+				got = append(got, fmt.Sprintf("%s %s %d", e.Type, e.EncodingID, e.Progress))
+				return nil
+			},
+			EncodingCompleted: func(e *Event) error {
+				if failCompleted {
+					failCompleted = false
+					return errors.New("handler failed")
+				}
+				got = append(got, fmt.Sprintf("%s %s", e.Type, e.EncodingID))
+				return nil
+			},
+		},
+	}
+	steps := []struct {
+		vs  []Video
+		es  []Encoding
+		exp []string
+	}{
+		{
+			[]Video{{ID: "old", Status: StatusSuccess}},
+			nil,
+			nil,
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusProcessing}, {ID: "old", Status: StatusSuccess}},
+			nil,
+			[]string{"video-created v1"},
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusSuccess}, {ID: "old", Status: StatusSuccess}},
+			[]Encoding{{ID: "e1", VideoID: "v1", ProfileID: "p1", Status: StatusProcessing}},
+			[]string{"video-encoded v1 map[p1:e1]", "encoding-progress e1 0"},
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusSuccess}},
+			[]Encoding{{ID: "e1", VideoID: "v1", ProfileID: "p1", Status: StatusProcessing, EncodingProgress: 40}},
+			[]string{"encoding-progress e1 40"},
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusSuccess}},
+			[]Encoding{{ID: "e1", VideoID: "v1", ProfileID: "p1", Status: StatusSuccess, EncodingProgress: 100}},
+			nil,
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusSuccess}},
+			[]Encoding{{ID: "e1", VideoID: "v1", ProfileID: "p1", Status: StatusSuccess, EncodingProgress: 100}},
+			[]string{"encoding-complete e1"},
+		},
+		{
+			[]Video{{ID: "v1", Status: StatusSuccess}},
+			[]Encoding{{ID: "e1", VideoID: "v1", ProfileID: "p1", Status: StatusSuccess, EncodingProgress: 100}},
+			nil,
+		},
+	}
+	for i, s := range steps {
+		mu.Lock()
+		vs, es = s.vs, s.es
+		mu.Unlock()
+		got = nil
+		if err := p.Poll(); err != nil {
+			t.Fatalf("want err=nil; got %v (i=%d)", err, i)
+		}
+		if !reflect.DeepEqual(got, s.exp) {
+			t.Errorf("want events=%q; got %q (i=%d)", s.exp, got, i)
+		}
+	}
+}
+
+func TestPollerPages(t *testing.T) {
+	var mu sync.Mutex
+	vs := []Video{{ID: "v1", Status: StatusProcessing}, {ID: "v0", Status: StatusSuccess}}
+	var pages []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v2/videos.json" {
+			mustWrite(w, []byte(`[]`))
+			return
+		}
+		pages = append(pages, r.URL.Query().Get("page"))
+		// one video per page
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		v := []Video{}
+		if page <= len(vs) {
+			v = vs[page-1 : page]
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+
+	var got []string
+	p := &Poller{
+		Manager: newManager(ts.URL, t),
+		PerPage: 1,
+		Handler: &WebhookHandler{
+			VideoCreated: func(e *Event) error {
+				got = append(got, fmt.Sprintf("%s %s", e.Type, e.VideoID))
+				return nil
+			},
+			VideoEncoded: func(e *Event) error {
+				got = append(got, fmt.Sprintf("%s %s", e.Type, e.VideoID))
+				return nil
+			},
+		},
+	}
+	if err := p.Poll(); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	mu.Lock()
+	vs = []Video{
+		{ID: "v3", Status: StatusProcessing},
+		{ID: "v2", Status: StatusProcessing},
+		{ID: "v1", Status: StatusSuccess},
+		{ID: "v0", Status: StatusSuccess},
+	}
+	pages = nil
+	mu.Unlock()
+	if err := p.Poll(); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := []string{"video-created v3", "video-created v2", "video-encoded v1"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("want events=%q; got %q", exp, got)
+	}
+	if exp := []string{"1", "2", "3"}; !reflect.DeepEqual(pages, exp) {
+		t.Errorf("want pages=%v; got %v", exp, pages)
+	}
+}
@@ -0,0 +1,200 @@
+package panda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// readOnlyProfileFields are set by Panda and cannot be updated
+var readOnlyProfileFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// UpdateProfile updates the fields of the profile with p.ID named by their JSON
+// names, e.g. "upscale" or "video_bitrate", to their values in p. Zero values are
+// sent as well, so that e.g. Upscale can be turned off, and fields which are not
+// named are not sent at all. Names of fields kept in p.Extra are accepted too.
+// The updated profile is returned and p is left unchanged.
+func (m *Manager) UpdateProfile(p *Profile, fields ...string) (*Profile, error) {
+	if p.ID == "" {
+		return nil, errors.New("panda: profile has no ID")
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("panda: no profile fields to update")
+	}
+	params, err := profileValues(p, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := m.Client.do("UpdateProfile", "PUT", fmt.Sprintf(profilesIdPath, p.ID), "", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	upd := new(Profile)
+	if err = json.Unmarshal(b, upd); err != nil {
+		return nil, err
+	}
+	return upd, nil
+}
+
+// profileValues encodes the named fields of p, including zero values
+func profileValues(p *Profile, fields []string) (url.Values, error) {
+	v := reflect.ValueOf(p).Elem()
+	index := make(map[string]int, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	extra := p.Extra.Values()
+	params := make(url.Values, len(fields))
+	for _, name := range fields {
+		if readOnlyProfileFields[name] {
+			return nil, fmt.Errorf("panda: profile field %q cannot be updated", name)
+		}
+		i, ok := index[name]
+		if !ok {
+			if _, ok = extra[name]; !ok {
+				return nil, fmt.Errorf("panda: unknown profile field %q", name)
+			}
+			params[name] = extra[name]
+			continue
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			params.Set(name, f.String())
+		case reflect.Bool:
+			params.Set(name, strconv.FormatBool(f.Bool()))
+		case reflect.Int, reflect.Int64:
+			params.Set(name, strconv.FormatInt(f.Int(), 10))
+		case reflect.Float64:
+			params.Set(name, strconv.FormatFloat(f.Float(), 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("panda: profile field %q cannot be updated", name)
+		}
+	}
+	return params, nil
+}
+
+// NewProfileRequest returns a request creating a profile with the settings of p,
+// including the unknown ones kept in Extra. The fields managed by Panda, ID,
+// CreatedAt and UpdatedAt, are left out.
+func (p *Profile) NewProfileRequest() (*NewProfileRequest, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	pr := new(NewProfileRequest)
+	if err = json.Unmarshal(b, pr); err != nil {
+		return nil, err
+	}
+	pr.Extra = nil
+	for k, v := range p.Extra {
+		if pr.Extra == nil {
+			pr.Extra = make(Extra, len(p.Extra))
+		}
+		pr.Extra[k] = v
+	}
+	return pr, nil
+}
+
+// CloneOptions configures CloneProfile and CloneProfiles
+type CloneOptions struct {
+	// Target creates the clones, e.g. a Manager of another cloud. The profiles
+	// are cloned within the same cloud if nil, where a clone keeping the name of
+	// its profile is named like ConflictRename does, e.g. h264_2.
+	Target *Manager
+	// Override is called with the request for every clone before it is created,
+	// e.g. to change the name or resolution
+	Override func(*NewProfileRequest)
+}
+
+// CloneProfile fetches the profile with the given id and creates a copy of it
+// with the overrides applied
+func (m *Manager) CloneProfile(id string, opts *CloneOptions) (*Profile, error) {
+	existing, err := m.cloneNames(opts)
+	if err != nil {
+		return nil, err
+	}
+	return m.cloneProfile(id, opts, existing)
+}
+
+// cloneNames returns the profiles of the current cloud by name if opts clones
+// within it, nil otherwise
+func (m *Manager) cloneNames(opts *CloneOptions) (map[string]*Profile, error) {
+	if opts != nil && opts.Target != nil {
+		return nil, nil
+	}
+	ps, err := m.AllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*Profile, len(ps))
+	for i := range ps {
+		existing[ps[i].Name] = &ps[i]
+	}
+	return existing, nil
+}
+
+// cloneProfile clones the profile with the given id. Clones within the current
+// cloud get a free name if they keep their profile's, existing holds the
+// profiles by name and the clone is added to it.
+func (m *Manager) cloneProfile(id string, opts *CloneOptions, existing map[string]*Profile) (*Profile, error) {
+	if opts == nil {
+		opts = &CloneOptions{}
+	}
+	target := opts.Target
+	if target == nil {
+		target = m
+	}
+	p, err := m.Profile(id)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := p.NewProfileRequest()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Override != nil {
+		opts.Override(pr)
+	}
+	if existing != nil && pr.Name == p.Name {
+		pr.Name = freeName(p.Name, existing)
+	}
+	c, err := target.NewProfile(pr)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		existing[c.Name] = c
+	}
+	return c, nil
+}
+
+// CloneProfiles clones the profiles with the given ids one by one and returns a
+// map from the original to the new profile IDs. It stops at the first error and
+// returns the profiles cloned so far along with it.
+func (m *Manager) CloneProfiles(ids []string, opts *CloneOptions) (map[string]string, error) {
+	cloned := make(map[string]string, len(ids))
+	existing, err := m.cloneNames(opts)
+	if err != nil {
+		return cloned, err
+	}
+	for _, id := range ids {
+		p, err := m.cloneProfile(id, opts, existing)
+		if err != nil {
+			return cloned, fmt.Errorf("panda: cloning profile %s: %v", id, err)
+		}
+		cloned[id] = p.ID
+	}
+	return cloned, nil
+}
@@ -0,0 +1,198 @@
+package panda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	var q url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/v2/profiles/p1.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q = r.URL.Query()
+		mustWrite(w, []byte(`{"id":"p1","name":"h264","video_bitrate":0}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	p := &Profile{
+		ID:           "p1",
+		Name:         "h264",
+		Title:        "H.264",
+		Width:        640,
+		Extra:        Extra{"hdr_mode": json.RawMessage(`"pq"`)},
+		AddTimestamp: false,
+	}
+	upd, err := m.UpdateProfile(p, "upscale", "add_timestamp", "video_bitrate", "fps", "aspect_mode", "hdr_mode")
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if upd.ID != "p1" || upd == p {
+		t.Errorf("want updated profile to be returned; got %+v", upd)
+	}
+	exp := map[string]string{
+		"upscale":       "false",
+		"add_timestamp": "false",
+		"video_bitrate": "0",
+		"fps":           "0",
+		"aspect_mode":   "",
+		"hdr_mode":      "pq",
+	}
+	for k, v := range exp {
+		if vs, ok := q[k]; !ok || !reflect.DeepEqual(vs, []string{v}) {
+			t.Errorf("want %s=%q; got %q", k, v, vs)
+		}
+	}
+	for _, k := range []string{"name", "title", "width"} {
+		if _, ok := q[k]; ok {
+			t.Errorf("want %s not to be sent", k)
+		}
+	}
+
+	cases := []struct {
+		p      *Profile
+		fields []string
+	}{
+		{&Profile{}, []string{"name"}},
+		{p, nil},
+		{p, []string{"created_at"}},
+		{p, []string{"id"}},
+		{p, []string{"no_such_field"}},
+	}
+	for i, c := range cases {
+		if _, err := m.UpdateProfile(c.p, c.fields...); err == nil {
+			t.Errorf("want err!=nil (i=%d)", i)
+		}
+	}
+}
+
+func TestCloneProfiles(t *testing.T) {
+	src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/profiles/p1.json":
+			mustWrite(w, []byte(`{"id":"p1","name":"h264","width":1280,"height":720,"upscale":true,"tiles":4,"created_at":"2016/01/01 12:00:00 +0000"}`))
+		case "/v2/profiles/p2.json":
+			mustWrite(w, []byte(`{"id":"p2","name":"webm","width":640}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			mustWrite(w, []byte(`{"error":"RecordNotFound","message":"no profile"}`))
+		}
+	}))
+	defer src.Close()
+	var created []url.Values
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v2/profiles.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		created = append(created, q)
+		b, err := json.Marshal(&Profile{ID: "new-" + q.Get("name"), Name: q.Get("name")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustWrite(w, b)
+	}))
+	defer dst.Close()
+
+	m := newManager(src.URL, t)
+	opts := &CloneOptions{
+		Target: newManager(dst.URL, t),
+		Override: func(pr *NewProfileRequest) {
+			pr.Name += "_eu"
+			pr.Height /= 2
+		},
+	}
+	ids, err := m.CloneProfiles([]string{"p1", "p2"}, opts)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if exp := map[string]string{"p1": "new-h264_eu", "p2": "new-webm_eu"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("want ids=%v; got %v", exp, ids)
+	}
+	if len(created) != 2 {
+		t.Fatalf("want 2 profiles to be created; got %d", len(created))
+	}
+	for k, v := range map[string]string{"name": "h264_eu", "width": "1280", "height": "360", "upscale": "true", "tiles": "4"} {
+		if got := created[0].Get(k); got != v {
+			t.Errorf("want %s=%q; got %q", k, v, got)
+		}
+	}
+	for _, k := range []string{"id", "created_at", "updated_at"} {
+		if _, ok := created[0][k]; ok {
+			t.Errorf("want %s not to be sent", k)
+		}
+	}
+
+	ids, err = m.CloneProfiles([]string{"p1", "missing"}, opts)
+	if err == nil {
+		t.Error("want err!=nil")
+	}
+	if len(ids) != 1 {
+		t.Errorf("want profiles cloned before the error; got %v", ids)
+	}
+}
+
+func TestCloneProfilesSameCloud(t *testing.T) {
+	var mu sync.Mutex
+	profiles := []Profile{{ID: "p1", Name: "h264"}, {ID: "p2", Name: "h264_2"}, {ID: "p3", Name: "webm"}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var v interface{}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/profiles.json":
+			v = []Profile{}
+			if r.URL.Query().Get("page") == "1" {
+				v = profiles
+			}
+		case r.Method == "GET":
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/profiles/"), ".json")
+			for _, p := range profiles {
+				if p.ID == id {
+					v = p
+				}
+			}
+		case r.Method == "POST":
+			p := Profile{ID: "p" + strconv.Itoa(len(profiles)+1), Name: r.URL.Query().Get("name")}
+			profiles = append(profiles, p)
+			v = p
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustWrite(w, b)
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	ids, err := m.CloneProfiles([]string{"p1", "p1", "p3"}, &CloneOptions{
+		Override: func(pr *NewProfileRequest) {
+			if pr.Name == "webm" {
+				pr.Name = "webm_copy"
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	var names []string
+	for _, p := range profiles[3:] {
+		names = append(names, p.Name)
+	}
+	if exp := []string{"h264_3", "h264_4", "webm_copy"}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("want clones named %v; got %v", exp, names)
+	}
+	if ids["p3"] != "p6" {
+		t.Errorf("want p3 cloned to p6; got %v", ids)
+	}
+}
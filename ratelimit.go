@@ -0,0 +1,224 @@
+package panda
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket rate limiter with a cap on the number of requests
+// in flight. It is safe for concurrent use and is meant to be shared by all
+// goroutines using the same Client:
+//
+//	cl.Use(panda.NewRateLimiter(10, 5, 4).Middleware())
+//
+// When Panda responds with 429 Too Many Requests, or reports through the
+// X-RateLimit-Remaining and X-RateLimit-Reset headers that no requests are left,
+// the limiter stops issuing requests until the server's reset time and halves its
+// rate. The rate then recovers gradually with every successful call.
+type RateLimiter struct {
+	// Rate is the maximum number of requests per second, zero means no limit
+	Rate float64
+	// Burst is the maximum number of requests issued at once, at least one
+	Burst int
+	// MaxInFlight caps the number of concurrent requests, zero means no cap
+	MaxInFlight int
+	// MaxRetries is the number of times a call rejected with 429 is retried
+	MaxRetries int
+
+	mu          sync.Mutex
+	inited      bool
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	sem         chan struct{}
+}
+
+// NewRateLimiter creates a rate limiter issuing at most rate requests per second,
+// with the given burst and maximum number of requests in flight. Calls rejected
+// with 429 are retried up to three times.
+func NewRateLimiter(rate float64, burst, maxInFlight int) *RateLimiter {
+	return &RateLimiter{
+		Rate:        rate,
+		Burst:       burst,
+		MaxInFlight: maxInFlight,
+		MaxRetries:  3,
+	}
+}
+
+func (rl *RateLimiter) init() {
+	if !rl.inited {
+		rl.inited = true
+		rl.rate = rl.Rate
+		rl.tokens = float64(rl.burst())
+		rl.last = time.Now()
+		if rl.MaxInFlight > 0 {
+			rl.sem = make(chan struct{}, rl.MaxInFlight)
+		}
+	}
+}
+
+func (rl *RateLimiter) burst() int {
+	if rl.Burst < 1 {
+		return 1
+	}
+	return rl.Burst
+}
+
+// CurrentRate returns the rate the limiter currently issues requests at, which is
+// lower than Rate after the server has pushed back
+func (rl *RateLimiter) CurrentRate() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.init()
+	return rl.rate
+}
+
+// Wait blocks until the limiter allows another request
+func (rl *RateLimiter) Wait() {
+	rl.WaitContext(context.Background())
+}
+
+// WaitContext blocks until the limiter allows another request or ctx is done, in
+// which case ctx.Err() is returned
+func (rl *RateLimiter) WaitContext(ctx context.Context) error {
+	for {
+		d := rl.reserve()
+		if d <= 0 {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// reserve takes a token and returns zero or returns how long to wait for one
+func (rl *RateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.init()
+	now := time.Now()
+	if now.Before(rl.pausedUntil) {
+		return rl.pausedUntil.Sub(now)
+	}
+	if rl.Rate <= 0 {
+		return 0
+	}
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if max := float64(rl.burst()); rl.tokens > max {
+		rl.tokens = max
+	}
+	rl.last = now
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+	return time.Duration((1 - rl.tokens) / rl.rate * float64(time.Second))
+}
+
+func (rl *RateLimiter) acquire(ctx context.Context) (func(), error) {
+	rl.mu.Lock()
+	rl.init()
+	sem := rl.sem
+	rl.mu.Unlock()
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	}
+}
+
+// observe adapts the limiter to the server's response and reports whether the
+// call was rejected because of rate limiting
+func (rl *RateLimiter) observe(resp *Response) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	limited := resp.StatusCode == http.StatusTooManyRequests
+	if limited {
+		wait := time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait = time.Duration(s) * time.Second
+		}
+		rl.pause(now.Add(wait))
+		if min := rl.Rate / 16; rl.rate/2 > min {
+			rl.rate /= 2
+		} else {
+			rl.rate = min
+		}
+		return true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			rl.pause(time.Unix(reset, 0))
+		}
+	}
+	if rl.rate < rl.Rate {
+		if rl.rate += rl.Rate / 10; rl.rate > rl.Rate {
+			rl.rate = rl.Rate
+		}
+	}
+	return false
+}
+
+func (rl *RateLimiter) pause(until time.Time) {
+	if until.After(rl.pausedUntil) {
+		rl.pausedUntil = until
+		rl.tokens, rl.last = 0, until
+	}
+}
+
+// Middleware returns a Middleware limiting all calls going through it
+func (rl *RateLimiter) Middleware() Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(c *Call) (*Response, error) {
+			var body []byte
+			if c.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(c.Body); err != nil {
+					return nil, err
+				}
+			}
+			for {
+				if body != nil {
+					c.Body = bytes.NewReader(body)
+				}
+				resp, err := rl.call(next, c)
+				if err != nil || !rl.observe(resp) || c.Retries >= rl.MaxRetries {
+					return resp, err
+				}
+				c.Retries++
+			}
+		}
+	}
+}
+
+func (rl *RateLimiter) call(next CallHandler, c *Call) (*Response, error) {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := rl.WaitContext(ctx); err != nil {
+		return nil, err
+	}
+	release, err := rl.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return next(c)
+}
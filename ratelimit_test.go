@@ -0,0 +1,137 @@
+package panda
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterInFlight(t *testing.T) {
+	var cur, max int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&cur, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&cur, -1)
+		mustWrite(w, []byte(`{}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	m.Client.Use(NewRateLimiter(1000, 20, 3).Middleware())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Encoding("1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max > 3 {
+		t.Errorf("want at most 3 requests in flight; got %d", max)
+	}
+}
+
+func TestRateLimiterInFlightUnlimitedRate(t *testing.T) {
+	var cur, max int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&cur, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&cur, -1)
+		mustWrite(w, []byte(`{}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	m.Client.Use((&RateLimiter{MaxInFlight: 1}).Middleware())
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Encoding("1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max != 1 {
+		t.Errorf("want 1 request in flight; got %d", max)
+	}
+}
+
+func TestRateLimiterWaitContext(t *testing.T) {
+	rl := NewRateLimiter(1, 1, 0)
+	if err := rl.WaitContext(context.Background()); err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := rl.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("want err=%v; got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("want cancelled wait to return early; took %v", d)
+	}
+}
+
+func TestRateLimiterRate(t *testing.T) {
+	rl := NewRateLimiter(100, 1, 0)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		rl.Wait()
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("want 6 requests at 100/s to take at least 40ms; took %v", d)
+	}
+}
+
+func TestRateLimiterTooManyRequests(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		mustWrite(w, []byte(`{"id":"e1"}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	rl := NewRateLimiter(100, 10, 0)
+	var retries int
+	m.Client.Use(func(next CallHandler) CallHandler {
+		return func(c *Call) (*Response, error) {
+			resp, err := next(c)
+			retries = c.Retries
+			return resp, err
+		}
+	}, rl.Middleware())
+	e, err := m.Encoding("e1")
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if e.ID != "e1" || calls != 2 || retries != 1 {
+		t.Errorf("want one retry; got id=%s calls=%d retries=%d", e.ID, calls, retries)
+	}
+	if r := rl.CurrentRate(); r >= 100 {
+		t.Errorf("want rate to be lowered; got %v", r)
+	}
+}
@@ -0,0 +1,155 @@
+package panda
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPresignExpiry is the longest lifetime S3 accepts for pre-signed URLs
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// S3Signer produces AWS Signature Version 4 pre-signed GET URLs for outputs
+// stored in clouds with S3PrivateAccess. It implements URLSigner.
+type S3Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is required for temporary credentials only
+	SessionToken string
+	// Region defaults to us-east-1
+	Region string
+	// Endpoint overrides the S3 endpoint, e.g. for S3 compatible storage. Buckets are
+	// addressed path-style when it is set.
+	Endpoint string
+
+	now func() time.Time
+}
+
+func (s *S3Signer) region() string {
+	if s.Region == "" {
+		return "us-east-1"
+	}
+	return s.Region
+}
+
+// SignURL returns a pre-signed URL for the given key in the cloud's bucket
+func (s *S3Signer) SignURL(c *Cloud, key string, expires time.Duration) (string, error) {
+	if c.S3VideosBucket == "" {
+		return "", errors.New("panda: cloud has no S3 bucket")
+	}
+	return s.PresignGet(c.S3VideosBucket, key, expires)
+}
+
+// PresignGet returns a URL which allows a GET of the given object until it expires
+func (s *S3Signer) PresignGet(bucket, key string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > maxPresignExpiry {
+		return "", fmt.Errorf("panda: expiry must be between 1s and %v", maxPresignExpiry)
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	scope := date + "/" + s.region() + "/s3/aws4_request"
+
+	u, err := s.objectURL(bucket, key)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.AccessKeyID+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	q.Set("X-Amz-SignedHeaders", "host")
+	if s.SessionToken != "" {
+		q.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	canonicalQuery := canonicalQueryString(q)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(crHash[:]),
+	}, "\n")
+	key4 := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key4 = hmacSHA256(key4, s.region())
+	key4 = hmacSHA256(key4, "s3")
+	key4 = hmacSHA256(key4, "aws4_request")
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + hex.EncodeToString(hmacSHA256(key4, toSign))
+	return u.String(), nil
+}
+
+func (s *S3Signer) objectURL(bucket, key string) (*url.URL, error) {
+	p := "/" + s3Escape(strings.TrimPrefix(key, "/"))
+	if s.Endpoint != "" {
+		u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+		if err != nil {
+			return nil, err
+		}
+		u.RawPath = u.Path + "/" + s3Escape(bucket) + p
+		u.Path, _ = url.PathUnescape(u.RawPath)
+		return u, nil
+	}
+	host := bucket + ".s3.amazonaws.com"
+	if r := s.region(); r != "us-east-1" {
+		host = bucket + ".s3." + r + ".amazonaws.com"
+	}
+	path, _ := url.PathUnescape(p)
+	return &url.URL{Scheme: "https", Host: host, Path: path, RawPath: p}, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQueryString(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, s3Escape(k)+"="+strings.Replace(s3Escape(v), "/", "%2F", -1))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters and slashes,
+// as required for SigV4 canonical URIs
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package panda
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ernesto-jimenez/go-querystring/query"
+)
+
+// defaultChunkSize is the size of the chunks a file is uploaded in
+const defaultChunkSize = 5 << 20
+
+// defaultRetryDelay is the delay before the first retry of a failed chunk
+const defaultRetryDelay = 500 * time.Millisecond
+
+// ErrNoUploadedVideo is returned by UploadTo if the storage did not respond to the
+// last chunk with the created video
+var ErrNoUploadedVideo = errors.New("panda: upload finished without a video")
+
+// UploadSession is a location a file can be uploaded to directly, bypassing the API host
+type UploadSession struct {
+	ID       string `json:"id"`
+	Location string `json:"location"`
+}
+
+// UploadOptions configure direct uploads
+type UploadOptions struct {
+	// ChunkSize defaults to 5 MiB
+	ChunkSize int64
+	// Retries is the number of times a failed chunk is sent again, zero sends every
+	// chunk once. Nil options retry three times.
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled for every further
+	// one. Defaults to 500ms.
+	RetryDelay time.Duration
+	// Progress is called after every chunk which has been stored
+	Progress func(sent, total int64)
+}
+
+// NewUploadSession creates an upload session for a file with the given name and size
+func (m *Manager) NewUploadSession(name string, size int64, vr *NewVideoRequest) (*UploadSession, error) {
+	params, err := query.Values(vr)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("file_name", name)
+	params.Set("file_size", strconv.FormatInt(size, 10))
+	b, err := m.Client.do("NewUploadSession", "POST", videosUploadPath, "", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := new(UploadSession)
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UploadFile uploads the given file directly to storage and returns the created video
+func (m *Manager) UploadFile(file string, vr *NewVideoRequest, opts *UploadOptions) (*Video, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return m.Upload(f, filepath.Base(file), fi.Size(), vr, opts)
+}
+
+// Upload creates an upload session and sends size bytes read from r to its location
+// in chunks, so the data never passes through the API host. Once the last chunk
+// is stored the created video is fetched and returned.
+func (m *Manager) Upload(r io.ReaderAt, name string, size int64, vr *NewVideoRequest,
+	opts *UploadOptions) (*Video, error) {
+	s, err := m.NewUploadSession(name, size, vr)
+	if err != nil {
+		return nil, err
+	}
+	return m.UploadTo(s, r, size, opts)
+}
+
+// UploadTo sends size bytes read from r to the location of an existing session.
+// The storage responds to the last chunk with the created video, which is then
+// fetched and returned; ErrNoUploadedVideo is returned if it does not.
+func (m *Manager) UploadTo(s *UploadSession, r io.ReaderAt, size int64, opts *UploadOptions) (*Video, error) {
+	if opts == nil {
+		opts = &UploadOptions{Retries: 3}
+	}
+	chunk := opts.ChunkSize
+	if chunk <= 0 {
+		chunk = defaultChunkSize
+	}
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	var last []byte
+	for off := int64(0); off == 0 || off < size; off += chunk {
+		n := chunk
+		if off+n > size {
+			n = size - off
+		}
+		var err error
+		for attempt := 0; ; attempt++ {
+			if last, err = m.putChunk(s.Location, io.NewSectionReader(r, off, n), off, n, size); err == nil {
+				break
+			}
+			if attempt >= opts.Retries {
+				return nil, err
+			}
+			time.Sleep(delay << uint(attempt))
+		}
+		if opts.Progress != nil {
+			opts.Progress(off+n, size)
+		}
+	}
+	v := new(Video)
+	if len(bytes.TrimSpace(last)) > 0 {
+		if err := json.Unmarshal(last, v); err != nil {
+			return nil, err
+		}
+	}
+	if v.ID == "" {
+		return nil, ErrNoUploadedVideo
+	}
+	return m.Video(v.ID)
+}
+
+func (m *Manager) putChunk(location string, r io.Reader, off, n, size int64) ([]byte, error) {
+	req, err := http.NewRequest("PUT", location, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = n
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if size > 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", off, off+n-1, size))
+	}
+	resp, err := m.Client.httpclient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent, http.StatusPermanentRedirect:
+		return b, nil
+	}
+	e := &Error{Code: resp.StatusCode}
+	json.Unmarshal(b, e)
+	return nil, e
+}
@@ -0,0 +1,114 @@
+package panda
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpload(t *testing.T) {
+	content := strings.Repeat("0123456789", 25)
+	var stored bytes.Buffer
+	var ranges []string
+	failed := false
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v2/videos/upload.json":
+			q := r.URL.Query()
+			if q.Get("file_name") != "movie.mp4" || q.Get("file_size") != "250" || q.Get("profiles") != "h264" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"s1","location":"%s/upload/s1"}`, ts.URL)
+		case r.Method == "PUT" && r.URL.Path == "/upload/s1":
+			if r.URL.Query().Get("signature") != "" {
+				t.Error("want direct upload not to be signed")
+			}
+			rg := r.Header.Get("Content-Range")
+			if rg == "bytes 100-199/250" && !failed {
+				failed = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			stored.Write(b)
+			ranges = append(ranges, rg)
+			if strings.HasSuffix(rg, "-249/250") {
+				mustWrite(w, []byte(`{"id":"v1"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.URL.Path == "/v2/videos/v1.json":
+			mustWrite(w, []byte(`{"id":"v1","original_filename":"movie.mp4"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	var progress []int64
+	v, err := m.Upload(strings.NewReader(content), "movie.mp4", int64(len(content)),
+		&NewVideoRequest{Profiles: []string{"h264"}},
+		&UploadOptions{ChunkSize: 100, Retries: 1, RetryDelay: time.Millisecond, Progress: func(sent, total int64) { progress = append(progress, sent) }})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if v.ID != "v1" || v.OriginalFilename != "movie.mp4" {
+		t.Errorf("want video v1; got %#v", v)
+	}
+	if stored.String() != content {
+		t.Errorf("want stored content to match; got %d bytes", stored.Len())
+	}
+	exp := "bytes 0-99/250,bytes 100-199/250,bytes 200-249/250"
+	if strings.Join(ranges, ",") != exp {
+		t.Errorf("want ranges=%s; got %v", exp, ranges)
+	}
+	if fmt.Sprint(progress) != "[100 200 250]" {
+		t.Errorf("want progress=[100 200 250]; got %v", progress)
+	}
+}
+
+func TestUploadFailures(t *testing.T) {
+	var puts int
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/upload/unavailable":
+			puts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.Method == "PUT" && r.URL.Path == "/upload/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("want no request to %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	cases := []struct {
+		id    string
+		opts  *UploadOptions
+		puts  int
+		check func(error) bool
+	}{
+		{"unavailable", &UploadOptions{}, 1, func(err error) bool { return err != nil }},
+		{"unavailable", &UploadOptions{Retries: 2, RetryDelay: time.Millisecond}, 3, func(err error) bool { return err != nil }},
+		{"empty", nil, 0, func(err error) bool { return err == ErrNoUploadedVideo }},
+	}
+	for i, c := range cases {
+		puts = 0
+		s := &UploadSession{ID: c.id, Location: ts.URL + "/upload/" + c.id}
+		if _, err := m.UploadTo(s, strings.NewReader("data"), 4, c.opts); !c.check(err) {
+			t.Errorf("want upload to fail; got err=%v (i=%d)", err, i)
+		}
+		if puts != c.puts {
+			t.Errorf("want %d attempts; got %d (i=%d)", c.puts, puts, i)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package panda
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPrivateCloud is returned when URLs are requested for a cloud with
+// S3PrivateAccess without a URLSigner
+var ErrPrivateCloud = errors.New("panda: cloud has private access, a URLSigner is required")
+
+// URLSigner produces time-limited URLs for objects stored in clouds with S3PrivateAccess
+type URLSigner interface {
+	SignURL(c *Cloud, key string, expires time.Duration) (string, error)
+}
+
+// URLOptions configure how output URLs are resolved
+type URLOptions struct {
+	// Signer is required for clouds with S3PrivateAccess
+	Signer URLSigner
+	// Expires is the lifetime of signed URLs, defaults to one hour
+	Expires time.Duration
+	// Screenshots is the number of screenshots taken of the video, e.g. the
+	// FrameCount of its profile. No screenshot URLs are resolved if zero.
+	Screenshots int
+}
+
+// OutputURLs holds the canonical URLs of a video's or encoding's files
+type OutputURLs struct {
+	// Main is the URL of the main file, e.g. the MP4 file or the HLS master playlist
+	Main        OutputFile
+	Files       []OutputFile
+	Screenshots []OutputFile
+}
+
+// EncodingURLs resolves the URLs of all files of the given encoding. If c is nil the
+// client's cloud is fetched.
+func (m *Manager) EncodingURLs(c *Cloud, e *Encoding, opts *URLOptions) (*OutputURLs, error) {
+	return m.resolve(c, e.Path+e.Extname, encodingFiles(e), e.Path, opts)
+}
+
+// VideoURLs resolves the URLs of the source file and screenshots of the given video.
+// If c is nil the client's cloud is fetched.
+func (m *Manager) VideoURLs(c *Cloud, v *Video, opts *URLOptions) (*OutputURLs, error) {
+	main := v.Path + v.Extname
+	return m.resolve(c, main, []string{main}, v.Path, opts)
+}
+
+func (m *Manager) resolve(c *Cloud, main string, names []string, path string,
+	opts *URLOptions) (*OutputURLs, error) {
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	if c == nil {
+		var err error
+		if c, err = m.Cloud(m.Client.Options.CloudID); err != nil {
+			return nil, err
+		}
+	}
+	r := &urlResolver{cloud: c, opts: opts}
+	u := &OutputURLs{}
+	var err error
+	if u.Main, err = r.file(main); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		f, err := r.file(name)
+		if err != nil {
+			return nil, err
+		}
+		u.Files = append(u.Files, f)
+	}
+	for i := 1; i <= opts.Screenshots; i++ {
+		f, err := r.file(path + "_" + strconv.Itoa(i) + ".jpg")
+		if err != nil {
+			return nil, err
+		}
+		u.Screenshots = append(u.Screenshots, f)
+	}
+	return u, nil
+}
+
+type urlResolver struct {
+	cloud *Cloud
+	opts  *URLOptions
+}
+
+func (r *urlResolver) file(key string) (OutputFile, error) {
+	if !r.cloud.S3PrivateAccess {
+		return OutputFile{Name: key, URL: cloudBaseURL(r.cloud) + key}, nil
+	}
+	if r.opts.Signer == nil {
+		return OutputFile{}, ErrPrivateCloud
+	}
+	exp := r.opts.Expires
+	if exp <= 0 {
+		exp = time.Hour
+	}
+	u, err := r.opts.Signer.SignURL(r.cloud, key, exp)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	return OutputFile{Name: key, URL: u}, nil
+}
+
+// cloudBaseURL returns the cloud's URL with a trailing slash. Clouds without a URL
+// are served from their S3 bucket.
+func cloudBaseURL(c *Cloud) string {
+	if c.URL == "" {
+		return "https://" + c.S3VideosBucket + ".s3.amazonaws.com/"
+	}
+	return strings.TrimSuffix(c.URL, "/") + "/"
+}
@@ -0,0 +1,68 @@
+package panda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSigner struct{}
+
+func (testSigner) SignURL(c *Cloud, key string, expires time.Duration) (string, error) {
+	return "https://signed/" + c.S3VideosBucket + "/" + key + "?expires=" + expires.String(), nil
+}
+
+func TestEncodingURLs(t *testing.T) {
+	m := newManager("http://127.0.0.1:1", t)
+	c := &Cloud{URL: "http://bucket.s3.amazonaws.com", S3VideosBucket: "bucket"}
+	e := &Encoding{Path: "e1", Extname: ".m3u8", Files: []string{"e1.m3u8", "e1_0001.ts"}}
+	u, err := m.EncodingURLs(c, e, &URLOptions{Screenshots: 2})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := &OutputURLs{
+		Main: OutputFile{"e1.m3u8", "http://bucket.s3.amazonaws.com/e1.m3u8"},
+		Files: []OutputFile{
+			{"e1.m3u8", "http://bucket.s3.amazonaws.com/e1.m3u8"},
+			{"e1_0001.ts", "http://bucket.s3.amazonaws.com/e1_0001.ts"},
+		},
+		Screenshots: []OutputFile{
+			{"e1_1.jpg", "http://bucket.s3.amazonaws.com/e1_1.jpg"},
+			{"e1_2.jpg", "http://bucket.s3.amazonaws.com/e1_2.jpg"},
+		},
+	}
+	if !reflect.DeepEqual(u, exp) {
+		t.Errorf("want %#v; got %#v", exp, u)
+	}
+}
+
+func TestVideoURLsPrivate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mustWrite(w, []byte(`{"id":"1","s3_videos_bucket":"private","s3_private_access":true}`))
+	}))
+	defer ts.Close()
+	m := newManager(ts.URL, t)
+	v := &Video{Path: "v1", Extname: ".mp4"}
+	if _, err := m.VideoURLs(nil, v, nil); err != ErrPrivateCloud {
+		t.Errorf("want err=%v; got %v", ErrPrivateCloud, err)
+	}
+	u, err := m.VideoURLs(nil, v, &URLOptions{Signer: testSigner{}})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if len(u.Screenshots) != 0 {
+		t.Errorf("want no screenshots unless asked for; got %v", u.Screenshots)
+	}
+	u, err = m.VideoURLs(nil, v, &URLOptions{Signer: testSigner{}, Expires: time.Minute, Screenshots: 1})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if exp := "https://signed/private/v1.mp4?expires=1m0s"; u.Main.URL != exp {
+		t.Errorf("want url=%s; got %s", exp, u.Main.URL)
+	}
+	if exp := "https://signed/private/v1_1.jpg?expires=1m0s"; u.Screenshots[0].URL != exp {
+		t.Errorf("want url=%s; got %s", exp, u.Screenshots[0].URL)
+	}
+}
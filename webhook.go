@@ -0,0 +1,170 @@
+package panda
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType names a notification sent by Panda
+type EventType string
+
+const (
+	EventVideoCreated      = EventType("video-created")
+	EventVideoEncoded      = EventType("video-encoded")
+	EventEncodingProgress  = EventType("encoding-progress")
+	EventEncodingCompleted = EventType("encoding-complete")
+)
+
+// Event is a notification sent by Panda to the URL configured in Notification
+type Event struct {
+	Type       EventType `json:"event"`
+	VideoID    string    `json:"video_id,omitempty"`
+	EncodingID string    `json:"encoding_id,omitempty"`
+	// EncodingIDs maps profile IDs to the IDs of encodings created for a video
+	EncodingIDs map[string]string `json:"encoding_ids,omitempty"`
+	Progress    int               `json:"progress,omitempty"`
+	// Timestamp is sent by Panda, it is nil if the notification did not carry one
+	// or it was in an unknown format
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// Received is when the notification was received or synthesized
+	Received time.Time `json:"received"`
+}
+
+// Key identifies the event for deduplication. Deliveries of the same notification
+// have the same key. A notification without a timestamp cannot be told apart from
+// a later one of the same kind, so it is keyed by the time it was received
+// instead and is never deduplicated.
+func (e *Event) Key() string {
+	id := e.EncodingID
+	if id == "" {
+		id = e.VideoID
+	}
+	k := string(e.Type) + "/" + id
+	if e.Timestamp != nil {
+		k += "/" + strconv.FormatInt(e.Timestamp.UnixNano(), 10)
+	} else if !e.Received.IsZero() {
+		k += "/received/" + strconv.FormatInt(e.Received.UnixNano(), 10)
+	}
+	if e.Type == EventEncodingProgress {
+		k += "/" + strconv.Itoa(e.Progress)
+	}
+	return k
+}
+
+// ParseEvent reads a notification from the form values of the request
+func ParseEvent(r *http.Request) (*Event, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	e := &Event{
+		Type:       EventType(r.Form.Get("event")),
+		VideoID:    r.Form.Get("video_id"),
+		EncodingID: r.Form.Get("encoding_id"),
+		Received:   time.Now().UTC(),
+	}
+	if e.Type == "" {
+		return nil, errors.New("panda: notification has no event")
+	}
+	if p := r.Form.Get("progress"); p != "" {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, err
+		}
+		e.Progress = int(f)
+	}
+	if t, ok := parseTimestamp(r.Form.Get("timestamp")); ok {
+		e.Timestamp = &t
+	}
+	for k, v := range r.Form {
+		if strings.HasPrefix(k, "encoding_ids[") && strings.HasSuffix(k, "]") && len(v) > 0 {
+			if e.EncodingIDs == nil {
+				e.EncodingIDs = map[string]string{}
+			}
+			e.EncodingIDs[k[len("encoding_ids["):len(k)-1]] = v[0]
+		}
+	}
+	return e, nil
+}
+
+// timestampLayouts are the formats notification timestamps are accepted in
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006/01/02 15:04:05 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// parseTimestamp parses a timestamp in one of timestampLayouts or in seconds since
+// the Unix epoch. It reports false if s is empty or in none of these formats.
+func parseTimestamp(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, l := range timestampLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// WebhookHandler is an http.Handler receiving Panda notifications and passing them
+// to the function set for their type. Events of types without a function are
+// acknowledged and ignored. If a function returns an error the notification is
+// answered with 500, so that Panda delivers it again later.
+type WebhookHandler struct {
+	VideoCreated      func(*Event) error
+	VideoEncoded      func(*Event) error
+	EncodingProgress  func(*Event) error
+	EncodingCompleted func(*Event) error
+}
+
+// Dispatch calls the function set for the event's type
+func (h *WebhookHandler) Dispatch(e *Event) error {
+	var fn func(*Event) error
+	switch e.Type {
+	case EventVideoCreated:
+		fn = h.VideoCreated
+	case EventVideoEncoded:
+		fn = h.VideoEncoded
+	case EventEncodingProgress:
+		fn = h.EncodingProgress
+	case EventEncodingCompleted:
+		fn = h.EncodingCompleted
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(e)
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveEvent(w, r, h.Dispatch)
+}
+
+func serveEvent(w http.ResponseWriter, r *http.Request, fn func(*Event) error) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	e, err := ParseEvent(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = fn(e); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
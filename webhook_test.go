@@ -0,0 +1,168 @@
+package panda
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postEvent(t *testing.T, h http.Handler, v url.Values) int {
+	r := httptest.NewRequest("POST", "/panda", strings.NewReader(v.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestParseEvent(t *testing.T) {
+	v := url.Values{
+		"event":              {"video-encoded"},
+		"video_id":           {"v1"},
+		"encoding_ids[p1]":   {"e1"},
+		"encoding_ids[p2]":   {"e2"},
+		"timestamp":          {"2016-01-01T12:00:00Z"},
+		"unrelated_property": {"x"},
+	}
+	r := httptest.NewRequest("POST", "/panda", strings.NewReader(v.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e, err := ParseEvent(r)
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	if e.Type != EventVideoEncoded || e.VideoID != "v1" {
+		t.Errorf("want video-encoded for v1; got %#v", e)
+	}
+	if exp := map[string]string{"p1": "e1", "p2": "e2"}; !reflect.DeepEqual(e.EncodingIDs, exp) {
+		t.Errorf("want encoding ids=%v; got %v", exp, e.EncodingIDs)
+	}
+	if e.Timestamp == nil || !e.Timestamp.Equal(time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("want timestamp to be parsed; got %v", e.Timestamp)
+	}
+}
+
+func TestParseEventTimestamp(t *testing.T) {
+	exp := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		ts string
+		ok bool
+	}{
+		{"2016-01-01T12:00:00Z", true},
+		{"2016-01-01T13:00:00.000+01:00", true},
+		{"2016-01-01 12:00:00 +0000", true},
+		{"2016-01-01 12:00:00 UTC", true},
+		{"2016/01/01 12:00:00 +0000", true},
+		{"Fri, 01 Jan 2016 12:00:00 +0000", true},
+		{"1451649600", true},
+		{"yesterday", false},
+		{"", false},
+	}
+	for i, c := range cases {
+		v := url.Values{"event": {"video-created"}, "video_id": {"v1"}, "timestamp": {c.ts}}
+		r := httptest.NewRequest("POST", "/panda", strings.NewReader(v.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		e, err := ParseEvent(r)
+		if err != nil {
+			t.Errorf("want err=nil; got %v (i=%d)", err, i)
+			continue
+		}
+		if c.ok && (e.Timestamp == nil || !e.Timestamp.Equal(exp)) {
+			t.Errorf("want timestamp=%v; got %v (i=%d)", exp, e.Timestamp, i)
+		}
+		if !c.ok && e.Timestamp != nil {
+			t.Errorf("want timestamp=nil; got %v (i=%d)", e.Timestamp, i)
+		}
+	}
+}
+
+func TestEventProcessor(t *testing.T) {
+	var completed []string
+	fail := true
+	p := &EventProcessor{
+		Handler: &WebhookHandler{
+			EncodingCompleted: func(e *Event) error {
+				if fail {
+					return errors.New("database unavailable")
+				}
+				completed = append(completed, e.EncodingID)
+				return nil
+			},
+		},
+		Store: NewMemoryStore(),
+	}
+	v := url.Values{
+		"event":       {"encoding-complete"},
+		"video_id":    {"v1"},
+		"encoding_id": {"e1"},
+		"timestamp":   {"2016-01-01T12:00:00Z"},
+	}
+	if code := postEvent(t, p, v); code != http.StatusInternalServerError {
+		t.Errorf("want failed handler to answer 500; got %d", code)
+	}
+	fail = false
+	for i := 0; i < 3; i++ {
+		if code := postEvent(t, p, v); code != http.StatusOK {
+			t.Errorf("want 200; got %d (i=%d)", code, i)
+		}
+	}
+	if code := postEvent(t, p, url.Values{"event": {"video-created"}, "video_id": {"v1"}}); code != http.StatusOK {
+		t.Errorf("want events without handler to be acknowledged; got %d", code)
+	}
+	if !reflect.DeepEqual(completed, []string{"e1"}) {
+		t.Errorf("want e1 to be processed once; got %v", completed)
+	}
+	v.Del("timestamp")
+	v.Set("encoding_id", "e2")
+	for i := 0; i < 2; i++ {
+		if code := postEvent(t, p, v); code != http.StatusOK {
+			t.Errorf("want 200; got %d (i=%d)", code, i)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !reflect.DeepEqual(completed, []string{"e1", "e2", "e2"}) {
+		t.Errorf("want events without timestamp not to be deduplicated; got %v", completed)
+	}
+
+	var replayed []EventType
+	err := p.Replay(time.Time{}, &WebhookHandler{
+		VideoCreated:      func(e *Event) error { replayed = append(replayed, e.Type); return nil },
+		EncodingCompleted: func(e *Event) error { replayed = append(replayed, e.Type); return nil },
+	})
+	if err != nil {
+		t.Fatalf("want err=nil; got %v", err)
+	}
+	exp := []EventType{EventEncodingCompleted, EventVideoCreated, EventEncodingCompleted, EventEncodingCompleted}
+	if !reflect.DeepEqual(replayed, exp) {
+		t.Errorf("want replayed=%v; got %v", exp, replayed)
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	ts := time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		e   Event
+		exp string
+	}{
+		{Event{Type: EventVideoEncoded, VideoID: "v1"}, "video-encoded/v1"},
+		{Event{Type: EventVideoEncoded, VideoID: "v1", Timestamp: &ts}, "video-encoded/v1/1451649600000000000"},
+		{Event{Type: EventVideoEncoded, VideoID: "v1", Received: ts}, "video-encoded/v1/received/1451649600000000000"},
+		{Event{Type: EventEncodingProgress, VideoID: "v1", EncodingID: "e1", Progress: 50}, "encoding-progress/e1/50"},
+	}
+	for i, c := range cases {
+		if k := c.e.Key(); k != c.exp {
+			t.Errorf("want key=%s; got %s (i=%d)", c.exp, k, i)
+		}
+	}
+	b, err := json.Marshal(&cases[0].e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "timestamp") {
+		t.Errorf("want no timestamp; got %s", b)
+	}
+}